@@ -0,0 +1,145 @@
+package admin
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// httpClient is shared by all Clients, a Client is created for every request to frpc and
+// sharing the transport keeps its idle connections from piling up.
+var httpClient = &http.Client{
+	Timeout: 5 * time.Second,
+	Transport: &http.Transport{
+		// the certificate of the admin api is not issued for the pod ip
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		IdleConnTimeout: 90 * time.Second,
+	},
+}
+
+// Client talks to the admin api of a running frpc.
+type Client struct {
+	URL        string
+	Username   string
+	Password   string
+	HTTPClient *http.Client
+}
+
+func NewClient(url string, username string, password string) *Client {
+	return &Client{
+		URL:        strings.TrimSuffix(url, "/"),
+		Username:   username,
+		Password:   password,
+		HTTPClient: httpClient,
+	}
+}
+
+// Config returns the config file frpc reads on its next start, frpc leaves out the token lines.
+func (c *Client) Config(ctx context.Context) (string, error) {
+	body, err := c.do(ctx, http.MethodGet, "/api/config")
+	if err != nil {
+		return "", err
+	}
+	return string(body), nil
+}
+
+// ConfigSynced reports whether the config file of frpc is one of the desired configs.
+// Depending on its version frpc returns the file as written or with the placeholders of
+// its environment filled in, so callers pass both.
+func (c *Client) ConfigSynced(ctx context.Context, desired ...string) (bool, error) {
+	current, err := c.Config(ctx)
+	if err != nil {
+		return false, err
+	}
+	for _, config := range desired {
+		if withoutToken(current) == withoutToken(config) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Proxy states reported by frpc.
+const (
+	StatusNew         = "new"
+	StatusWaitStart   = "wait start"
+	StatusStartError  = "start error"
+	StatusRunning     = "running"
+	StatusCheckFailed = "check failed"
+	StatusClosed      = "closed"
+)
+
+// ProxyStatus is the state of one proxy as reported by frpc.
+type ProxyStatus struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	Status     string `json:"status"`
+	Err        string `json:"err"`
+	LocalAddr  string `json:"local_addr"`
+	Plugin     string `json:"plugin"`
+	RemoteAddr string `json:"remote_addr"`
+}
+
+// Status returns the state of every proxy by name, frpc groups them by proxy type.
+func (c *Client) Status(ctx context.Context) (map[string]ProxyStatus, error) {
+	body, err := c.do(ctx, http.MethodGet, "/api/status")
+	if err != nil {
+		return nil, err
+	}
+	var byType map[string][]ProxyStatus
+	if err := json.Unmarshal(body, &byType); err != nil {
+		return nil, fmt.Errorf("frpc admin api /api/status: %w", err)
+	}
+	statuses := map[string]ProxyStatus{}
+	for _, proxies := range byType {
+		for _, proxy := range proxies {
+			statuses[proxy.Name] = proxy
+		}
+	}
+	return statuses, nil
+}
+
+// Stop exits frpc, the kubelet restarts the container which then reads the config file again.
+func (c *Client) Stop(ctx context.Context) error {
+	_, err := c.do(ctx, http.MethodPost, "/api/stop")
+	return err
+}
+
+func (c *Client) do(ctx context.Context, method string, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.URL+path, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.SetBasicAuth(c.Username, c.Password)
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("frpc admin api %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return body, nil
+}
+
+// withoutToken normalizes a config the way the admin api of frpc returns it.
+func withoutToken(config string) string {
+	var rows []string
+	for _, row := range strings.Split(config, "\n") {
+		row = strings.TrimSpace(row)
+		if strings.HasPrefix(row, "token") {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	return strings.Join(rows, "\n")
+}
@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFrpc starts a stub of the frpc admin api serving body on /api/status.
+func newFrpc(t *testing.T, body string) *httptest.Server {
+	return newFrpcRoutes(t, map[string]string{"/api/status": body})
+}
+
+// newFrpcRoutes starts a stub of the frpc admin api serving GET requests by path,
+// other methods are routed by method and path, e.g. "POST /api/stop".
+func newFrpcRoutes(t *testing.T, routes map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pwd, ok := r.BasicAuth(); !ok || user != "admin" || pwd != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		route := r.URL.Path
+		if r.Method != http.MethodGet {
+			route = r.Method + " " + route
+		}
+		body, ok := routes[route]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClientStatus(t *testing.T) {
+	server := newFrpc(t, `{
+		"tcp": [{"name": "ssh", "type": "tcp", "status": "running", "err": "", "local_addr": "ssh:22", "plugin": "", "remote_addr": ":6000"}],
+		"http": [{"name": "web", "type": "http", "status": "start error", "err": "router config conflict", "local_addr": "web:80", "plugin": "", "remote_addr": ""}],
+		"udp": []
+	}`)
+
+	statuses, err := NewClient(server.URL, "admin", "secret").Status(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("got %d proxies, want 2", len(statuses))
+	}
+	if ssh := statuses["ssh"]; ssh.Status != StatusRunning || ssh.RemoteAddr != ":6000" || ssh.Type != "tcp" {
+		t.Errorf("unexpected status of ssh: %+v", ssh)
+	}
+	if web := statuses["web"]; web.Status != StatusStartError || web.Err != "router config conflict" {
+		t.Errorf("unexpected status of web: %+v", web)
+	}
+}
+
+func TestClientStatusUnauthorized(t *testing.T) {
+	server := newFrpc(t, `{}`)
+
+	if _, err := NewClient(server.URL, "admin", "wrong").Status(context.Background()); err == nil {
+		t.Fatal("expected an error for wrong credentials")
+	}
+}
+
+func TestClientStatusInvalidBody(t *testing.T) {
+	server := newFrpc(t, `not json`)
+
+	if _, err := NewClient(server.URL, "admin", "secret").Status(context.Background()); err == nil {
+		t.Fatal("expected an error for an invalid body")
+	}
+}
+
+func TestClientConfigSynced(t *testing.T) {
+	// frpc before v0.52 returns the rendered config without the token
+	server := newFrpcRoutes(t, map[string]string{"/api/config": "[common]\nserver_addr = frps\nadmin_user = admin\n"})
+	c := NewClient(server.URL, "admin", "secret")
+	written := "[common]\nserver_addr = frps\ntoken = {{ .Envs.FRPC_SECRET_A }}\nadmin_user = {{ .Envs.FRPC_ADMIN_USER }}\n"
+	rendered := "[common]\nserver_addr = frps\ntoken = abc\nadmin_user = admin\n"
+
+	synced, err := c.ConfigSynced(context.Background(), written, rendered)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !synced {
+		t.Error("the rendered config is not recognized")
+	}
+	if synced, _ := c.ConfigSynced(context.Background(), written); synced {
+		t.Error("the config as written matches the rendered config")
+	}
+}
+
+func TestClientStop(t *testing.T) {
+	server := newFrpcRoutes(t, map[string]string{"POST /api/stop": ""})
+	if err := NewClient(server.URL, "admin", "secret").Stop(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// frpc before v0.51 has no stop endpoint
+	server = newFrpcRoutes(t, map[string]string{"/api/status": "{}"})
+	if err := NewClient(server.URL, "admin", "secret").Stop(context.Background()); err == nil {
+		t.Fatal("expected an error from frpc without the stop endpoint")
+	}
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -24,15 +25,145 @@ import (
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
 type ClientCommon struct {
-	ServerAddr string     `json:"server_addr"`
-	ServerPort int        `json:"server_port"`
-	Token      TokenValue `json:"token"`
+	ServerAddr string `json:"server_addr"`
+	ServerPort int    `json:"server_port"`
+	// Token is used unless auth selects another authentication method.
+	// +optional
+	Token TokenValue `json:"token,omitempty"`
+	// +optional
+	Auth *ClientAuth `json:"auth,omitempty"`
+	// +optional
+	TLS *ClientTLS `json:"tls,omitempty"`
+	// +optional
+	Transport *ClientTransport `json:"transport,omitempty"`
+	// OutboundProxy is used to reach frps through a corporate proxy.
+	// +optional
+	OutboundProxy *OutboundProxy `json:"http_proxy,omitempty"`
+	// DNSServer replaces the resolver of the pod for looking up frps, e.g. 8.8.8.8.
+	// +optional
+	DNSServer string `json:"dns_server,omitempty"`
+	// AdminAddr is the address the admin api listens on, set it to 127.0.0.1 to only
+	// serve the config-reload sidecar in the same pod.
+	// +kubebuilder:default="0.0.0.0"
+	// +optional
+	AdminAddr string `json:"admin_addr,omitempty"`
+	// +kubebuilder:default=7400
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=65535
+	// +optional
+	AdminPort int `json:"admin_port,omitempty"`
+	// +optional
+	AdminTLS *AdminTLS `json:"admin_tls,omitempty"`
+	// NatHoleSTUNServer is used by xtcp proxies and visitors to discover their public address.
+	// +optional
+	NatHoleSTUNServer string `json:"nat_hole_stun_server,omitempty"`
 	// 	TODO https://github.com/fatedier/frp/blob/dev/pkg/config/client.go full config
 }
 
+// TokenValue is set either inline or from a key of a Secret or ConfigMap in the namespace of the Client.
 type TokenValue struct {
-	Value string `json:"value"`
-	// TODO value from configmap or secret
+	// +optional
+	Value string `json:"value,omitempty"`
+	// +optional
+	ValueFrom *TokenValueSource `json:"valueFrom,omitempty"`
+}
+
+// TokenValueSource selects the source of the token, exactly one of the fields must be set.
+// A Secret is passed to frpc through its environment, it is not written into the generated config.
+type TokenValueSource struct {
+	// +optional
+	SecretKeyRef *corev1.SecretKeySelector `json:"secretKeyRef,omitempty"`
+	// +optional
+	ConfigMapKeyRef *corev1.ConfigMapKeySelector `json:"configMapKeyRef,omitempty"`
+}
+
+// ClientAuth selects how frpc authenticates to frps, at most one method may be set.
+type ClientAuth struct {
+	// +optional
+	OIDC *OIDCAuth `json:"oidc,omitempty"`
+	// +optional
+	AuthenticateHeartbeats bool `json:"authenticate_heartbeats,omitempty"`
+	// +optional
+	AuthenticateNewWorkConns bool `json:"authenticate_new_work_conns,omitempty"`
+}
+
+// OIDCAuth fetches a token with the client credentials grant and sends it to frps.
+type OIDCAuth struct {
+	ClientID     string                   `json:"client_id"`
+	ClientSecret corev1.SecretKeySelector `json:"client_secret"`
+	// +optional
+	Audience         string `json:"audience,omitempty"`
+	TokenEndpointURL string `json:"token_endpoint_url"`
+	// AdditionalEndpointParams are sent to the token endpoint along with the request.
+	// +optional
+	AdditionalEndpointParams map[string]string `json:"additional_endpoint_params,omitempty"`
+}
+
+// ClientTLS enables TLS between frpc and frps, the Secrets are mounted into the frpc container
+// and the pod is restarted when their content changes, e.g. after cert-manager renewed them.
+type ClientTLS struct {
+	// CertSecret is a kubernetes.io/tls Secret with the client certificate presented to frps.
+	// +optional
+	CertSecret string `json:"cert_secret,omitempty"`
+	// TrustedCASecret is a Secret whose ca.crt is used to verify frps.
+	// +optional
+	TrustedCASecret string `json:"trusted_ca_secret,omitempty"`
+	// ServerName overrides the name used to verify the certificate of frps.
+	// +optional
+	ServerName string `json:"server_name,omitempty"`
+}
+
+// AdminTLS serves the admin api over https.
+type AdminTLS struct {
+	// CertSecret is a kubernetes.io/tls Secret with the certificate of the admin api.
+	CertSecret string `json:"cert_secret"`
+}
+
+// ClientTransport tunes the connection between frpc and frps, unset fields keep the frpc defaults.
+type ClientTransport struct {
+	// +kubebuilder:validation:Enum=tcp;kcp;quic;websocket
+	// +optional
+	Protocol string `json:"protocol,omitempty"`
+	// TCPMux must match the tcp_mux setting of frps.
+	// +optional
+	TCPMux *bool `json:"tcp_mux,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	PoolCount int `json:"pool_count,omitempty"`
+	// HeartbeatInterval in seconds.
+	// +optional
+	HeartbeatInterval int `json:"heartbeat_interval,omitempty"`
+	// HeartbeatTimeout in seconds.
+	// +optional
+	HeartbeatTimeout int `json:"heartbeat_timeout,omitempty"`
+	// DialServerTimeout in seconds.
+	// +optional
+	DialServerTimeout int `json:"dial_server_timeout,omitempty"`
+	// ConnectServerLocalIP is the local address used to connect to frps.
+	// +optional
+	ConnectServerLocalIP string `json:"connect_server_local_ip,omitempty"`
+	// QUIC options are only valid with the quic protocol.
+	// +optional
+	QUIC *QUICOptions `json:"quic,omitempty"`
+}
+
+type QUICOptions struct {
+	// KeepalivePeriod in seconds.
+	// +optional
+	KeepalivePeriod int `json:"keepalive_period,omitempty"`
+	// MaxIdleTimeout in seconds.
+	// +optional
+	MaxIdleTimeout int `json:"max_idle_timeout,omitempty"`
+	// +optional
+	MaxIncomingStreams int `json:"max_incoming_streams,omitempty"`
+}
+
+type OutboundProxy struct {
+	// URL of the proxy without credentials, e.g. http://proxy.corp:3128 or socks5://proxy.corp:1080.
+	// +kubebuilder:validation:Pattern=`^(http|socks5|ntlm)://`
+	URL string `json:"url"`
+	// +optional
+	Credentials *Credentials `json:"credentials,omitempty"`
 }
 
 // ClientSpec defines the desired state of Client
@@ -41,16 +172,74 @@ type ClientSpec struct {
 	// Important: Run "make" to regenerate code after modifying this file
 
 	Common ClientCommon `json:"common"`
+	// FrpVersion selects the fatedier/frpc image and the config keys it understands.
+	// +kubebuilder:default="v0.44.0"
+	// +kubebuilder:validation:Pattern=`^v[0-9]+\.[0-9]+\.[0-9]+$`
+	// +optional
+	FrpVersion string `json:"frpVersion,omitempty"`
+	// ConfigFormat of the frpc config, inferred from the frpc version when unset:
+	// toml since v0.52, ini before.
+	// +kubebuilder:validation:Enum=ini;toml;yaml;json
+	// +optional
+	ConfigFormat string `json:"configFormat,omitempty"`
+	// Logging changes restart frpc in its container, the pod is kept.
+	// +optional
+	Logging *ClientLogging `json:"logging,omitempty"`
+}
+
+// ClientLogging configures the log of frpc, unset fields keep the frpc defaults.
+type ClientLogging struct {
+	// +kubebuilder:validation:Enum=trace;debug;info;warn;error
+	// +optional
+	LogLevel string `json:"log_level,omitempty"`
+	// LogFile is console or a path in the frpc container.
+	// +optional
+	LogFile string `json:"log_file,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	LogMaxDays int `json:"log_max_days,omitempty"`
+	// +optional
+	DisableLogColor bool `json:"disable_log_color,omitempty"`
 }
 
 // ClientStatus defines the observed state of Client
 type ClientStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Groups are the load balancing groups the proxies of this client belong to.
+	// +optional
+	Groups []string `json:"groups,omitempty"`
+	// ObservedGeneration is the generation of the Client the status was computed for.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Proxies is the number of proxies in the config.
+	// +optional
+	Proxies int `json:"proxies"`
+	// ConfigHash is the sha256 of the config in the ConfigMap.
+	// +optional
+	ConfigHash string `json:"configHash,omitempty"`
+	// +listType=map
+	// +listMapKey=type
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
+// Condition types of a Client.
+const (
+	// ClientFrpVersionSupported is False when the config uses features the frpVersion of the Client does not have.
+	ClientFrpVersionSupported = "FrpVersionSupported"
+	// ClientConfigRendered is True when the config of the Client and its proxies was written to the ConfigMap.
+	ClientConfigRendered = "ConfigRendered"
+	// ClientDeploymentAvailable mirrors the Available condition of the frpc Deployment.
+	ClientDeploymentAvailable = "DeploymentAvailable"
+	// ClientReady is True when frpc is available and reads the config in the ConfigMap,
+	// Unknown when that cannot be checked through the admin api of frpc.
+	ClientReady = "Ready"
+)
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Proxies",type=integer,JSONPath=`.status.proxies`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Client is the Schema for the clients API
 type Client struct {
@@ -0,0 +1,103 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ProxyPlugin lets frpc serve the connection itself instead of forwarding it to local_addr:local_port.
+// Exactly one of the plugin fields must be set.
+type ProxyPlugin struct {
+	// +optional
+	Socks5 *Socks5Plugin `json:"socks5,omitempty"`
+	// +optional
+	HTTPProxy *HTTPProxyPlugin `json:"http_proxy,omitempty"`
+	// +optional
+	StaticFile *StaticFilePlugin `json:"static_file,omitempty"`
+	// +optional
+	UnixDomainSocket *UnixDomainSocketPlugin `json:"unix_domain_socket,omitempty"`
+	// +optional
+	HTTPS2HTTP *HTTPS2HTTPPlugin `json:"https2http,omitempty"`
+	// +optional
+	HTTP2HTTPS *HTTP2HTTPSPlugin `json:"http2https,omitempty"`
+}
+
+// Credentials are a user name and a password read from a Secret.
+type Credentials struct {
+	User     string                   `json:"user"`
+	Password corev1.SecretKeySelector `json:"password"`
+}
+
+// PluginVolume is the subset of volume sources that can hold files for a plugin.
+// Exactly one of the fields must be set, they match the fields of a core/v1 VolumeSource.
+// Host paths are not offered, anyone allowed to create a Proxy could serve the node's files.
+type PluginVolume struct {
+	// +optional
+	PersistentVolumeClaim *corev1.PersistentVolumeClaimVolumeSource `json:"persistentVolumeClaim,omitempty"`
+	// +optional
+	ConfigMap *corev1.ConfigMapVolumeSource `json:"configMap,omitempty"`
+}
+
+type Socks5Plugin struct {
+	// +optional
+	Credentials *Credentials `json:"credentials,omitempty"`
+}
+
+type HTTPProxyPlugin struct {
+	// +optional
+	Credentials *Credentials `json:"credentials,omitempty"`
+}
+
+type StaticFilePlugin struct {
+	// Volume holds the files to serve, it is mounted read-only into the frpc container.
+	Volume PluginVolume `json:"volume"`
+	// SubPath is the directory inside the volume to serve.
+	// +optional
+	SubPath string `json:"sub_path,omitempty"`
+	// +optional
+	StripPrefix string `json:"strip_prefix,omitempty"`
+	// +optional
+	Credentials *Credentials `json:"credentials,omitempty"`
+}
+
+type UnixDomainSocketPlugin struct {
+	// Volume holds the socket, usually a PersistentVolumeClaim shared with the service.
+	Volume PluginVolume `json:"volume"`
+	// Path of the socket inside the volume.
+	Path string `json:"path"`
+}
+
+type HTTPS2HTTPPlugin struct {
+	// LocalAddr is the host:port of the plain HTTP service.
+	LocalAddr string `json:"local_addr"`
+	// CertSecret is a kubernetes.io/tls Secret holding the certificate served to clients.
+	CertSecret string `json:"cert_secret"`
+	// +optional
+	HostHeaderRewrite string `json:"host_header_rewrite,omitempty"`
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type HTTP2HTTPSPlugin struct {
+	// LocalAddr is the host:port of the HTTPS service.
+	LocalAddr string `json:"local_addr"`
+	// +optional
+	HostHeaderRewrite string `json:"host_header_rewrite,omitempty"`
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+}
@@ -17,6 +17,7 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -27,22 +28,172 @@ type TCPProxy struct {
 	RemotePort string `json:"remote_port"`
 }
 
+type UDPProxy struct {
+	RemotePort string `json:"remote_port"`
+}
+
+type HTTPProxy struct {
+	// +optional
+	CustomDomains []string `json:"custom_domains,omitempty"`
+	// +optional
+	SubDomain string `json:"subdomain,omitempty"`
+	// +optional
+	Locations []string `json:"locations,omitempty"`
+	// +optional
+	HostHeaderRewrite string `json:"host_header_rewrite,omitempty"`
+	// Headers are set on every request forwarded to the local service.
+	// +optional
+	Headers map[string]string `json:"headers,omitempty"`
+	// +optional
+	HTTPUser string `json:"http_user,omitempty"`
+	// +optional
+	HTTPPwd string `json:"http_pwd,omitempty"`
+}
+
+// HTTPSProxy routes TLS connections by SNI, the TLS session is terminated by the local service.
+type HTTPSProxy struct {
+	// +optional
+	CustomDomains []string `json:"custom_domains,omitempty"`
+	// +optional
+	SubDomain string `json:"subdomain,omitempty"`
+}
+
+// STCPProxy is only reachable through a visitor that knows the same sk.
+type STCPProxy struct {
+	SK corev1.SecretKeySelector `json:"sk"`
+}
+
+// XTCPProxy lets visitors connect directly by NAT hole punching instead of relaying through frps.
+type XTCPProxy struct {
+	SK corev1.SecretKeySelector `json:"sk"`
+}
+
+// SUDPProxy is the udp counterpart of STCPProxy.
+type SUDPProxy struct {
+	SK corev1.SecretKeySelector `json:"sk"`
+}
+
+// TCPMuxProxy shares a single frps port between many tcp services, routed by the HTTP CONNECT request.
+type TCPMuxProxy struct {
+	// +kubebuilder:validation:Enum=httpconnect
+	// +kubebuilder:default=httpconnect
+	// +optional
+	Multiplexer string `json:"multiplexer,omitempty"`
+	// +optional
+	CustomDomains []string `json:"custom_domains,omitempty"`
+	// +optional
+	SubDomain string `json:"subdomain,omitempty"`
+	// +optional
+	RouteByHTTPUser string `json:"route_by_http_user,omitempty"`
+	// +optional
+	HTTPUser string `json:"http_user,omitempty"`
+	// +optional
+	HTTPPwd string `json:"http_pwd,omitempty"`
+}
+
+// ProxyGroup puts the proxy into a load balancing group, frps spreads connections across
+// all proxies of the group, no matter which Client or namespace they belong to.
+// Only tcp, http and tcpmux proxies can be grouped.
+type ProxyGroup struct {
+	Name string `json:"name"`
+	// Key must be the same for all proxies of the group.
+	Key corev1.SecretKeySelector `json:"key"`
+}
+
+// ProxyHealthCheck lets frpc take the proxy offline while the local service is unhealthy,
+// which keeps frps from sending traffic of a load balancing group to a dead member.
+type ProxyHealthCheck struct {
+	// +kubebuilder:validation:Enum=tcp;http
+	Type string `json:"type"`
+	// URL is the path requested by the http check, e.g. /healthz.
+	// +optional
+	URL string `json:"url,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	TimeoutSeconds int `json:"timeout_s,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxFailed int `json:"max_failed,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	IntervalSeconds int `json:"interval_s,omitempty"`
+}
+
 // ProxySpec defines the desired state of Proxy
+// Exactly one of the proxy type fields (tcp, udp, http, https, stcp, xtcp, sudp, tcpmux) must be set.
 type ProxySpec struct {
-	Client    string   `json:"client"`
-	LocalAddr string   `json:"local_addr"`
-	LocalPort string   `json:"local_port"`
-	TCPProxy  TCPProxy `json:"tcp"`
+	Client string `json:"client"`
+	// LocalAddr and LocalPort are required unless plugin is set.
+	// +optional
+	LocalAddr string `json:"local_addr,omitempty"`
+	// +optional
+	LocalPort string `json:"local_port,omitempty"`
+	// +optional
+	Plugin *ProxyPlugin `json:"plugin,omitempty"`
+	// +optional
+	Group *ProxyGroup `json:"group,omitempty"`
+	// +optional
+	HealthCheck *ProxyHealthCheck `json:"health_check,omitempty"`
+
+	// UseEncryption encrypts the traffic between frpc and frps, defaults to true.
+	// +optional
+	UseEncryption *bool `json:"use_encryption,omitempty"`
+	// +optional
+	UseCompression bool `json:"use_compression,omitempty"`
+	// BandwidthLimit caps the traffic of the proxy, e.g. 512KB or 1MB.
+	// +kubebuilder:validation:Pattern=`^[0-9]+(KB|MB)$`
+	// +optional
+	BandwidthLimit string `json:"bandwidth_limit,omitempty"`
+	// ProxyProtocolVersion passes the real client address to the local service.
+	// +kubebuilder:validation:Enum=v1;v2
+	// +optional
+	ProxyProtocolVersion string `json:"proxy_protocol_version,omitempty"`
+
+	// +optional
+	TCPProxy *TCPProxy `json:"tcp,omitempty"`
+	// +optional
+	UDPProxy *UDPProxy `json:"udp,omitempty"`
+	// +optional
+	HTTPProxy *HTTPProxy `json:"http,omitempty"`
+	// +optional
+	HTTPSProxy *HTTPSProxy `json:"https,omitempty"`
+	// +optional
+	STCPProxy *STCPProxy `json:"stcp,omitempty"`
+	// +optional
+	XTCPProxy *XTCPProxy `json:"xtcp,omitempty"`
+	// +optional
+	SUDPProxy *SUDPProxy `json:"sudp,omitempty"`
+	// +optional
+	TCPMuxProxy *TCPMuxProxy `json:"tcpmux,omitempty"`
 }
 
-// ProxyStatus defines the observed state of Proxy
+// ProxyStatus defines the observed state of Proxy, it is polled from the admin api of frpc.
 type ProxyStatus struct {
-	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
-	// Important: Run "make" to regenerate code after modifying this file
+	// Phase is Pending until frpc loaded the proxy and Unknown while the admin api
+	// of frpc cannot be reached.
+	// +kubebuilder:validation:Enum=Pending;WaitStart;StartError;Running;CheckFailed;Closed;Unknown
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Reason says why the phase is Pending or Unknown, e.g. FrpcNotRunning.
+	// +optional
+	Reason string `json:"reason,omitempty"`
+	// LastError is the error frpc reported for the proxy.
+	// +optional
+	LastError string `json:"lastError,omitempty"`
+	// RemoteAddr is where frps serves the proxy, e.g. :6000.
+	// +optional
+	RemoteAddr string `json:"remoteAddr,omitempty"`
+	// LastTransitionTime is when the phase changed last.
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Client",type=string,JSONPath=`.spec.client`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Remote",type=string,JSONPath=`.status.remoteAddr`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // Proxy is the Schema for the proxies API
 type Proxy struct {
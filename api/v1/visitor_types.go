@@ -0,0 +1,101 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type STCPVisitor struct {
+	// ServerName is the name of the stcp proxy to visit.
+	ServerName string `json:"server_name"`
+	// SK must match the sk of the visited proxy.
+	SK corev1.SecretKeySelector `json:"sk"`
+}
+
+type XTCPVisitor struct {
+	// ServerName is the name of the xtcp proxy to visit.
+	ServerName string `json:"server_name"`
+	// SK must match the sk of the visited proxy.
+	SK corev1.SecretKeySelector `json:"sk"`
+	// FallbackTo is the name of a stcp Visitor on the same Client, used when hole punching fails.
+	// +optional
+	FallbackTo string `json:"fallback_to,omitempty"`
+	// +optional
+	FallbackTimeoutMs int `json:"fallback_timeout_ms,omitempty"`
+}
+
+type SUDPVisitor struct {
+	// ServerName is the name of the sudp proxy to visit.
+	ServerName string `json:"server_name"`
+	// SK must match the sk of the visited proxy.
+	SK corev1.SecretKeySelector `json:"sk"`
+}
+
+// VisitorSpec defines the desired state of Visitor
+// Exactly one of the visitor type fields (stcp, xtcp, sudp) must be set.
+type VisitorSpec struct {
+	Client string `json:"client"`
+	// +kubebuilder:default="0.0.0.0"
+	// +optional
+	BindAddr string `json:"bind_addr,omitempty"`
+	BindPort int    `json:"bind_port"`
+	// UseEncryption and UseCompression must match the visited proxy, encryption defaults to true.
+	// +optional
+	UseEncryption *bool `json:"use_encryption,omitempty"`
+	// +optional
+	UseCompression bool `json:"use_compression,omitempty"`
+
+	// +optional
+	STCPVisitor *STCPVisitor `json:"stcp,omitempty"`
+	// +optional
+	XTCPVisitor *XTCPVisitor `json:"xtcp,omitempty"`
+	// +optional
+	SUDPVisitor *SUDPVisitor `json:"sudp,omitempty"`
+}
+
+// VisitorStatus defines the observed state of Visitor
+type VisitorStatus struct {
+	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
+	// Important: Run "make" to regenerate code after modifying this file
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+
+// Visitor is the Schema for the visitors API
+type Visitor struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   VisitorSpec   `json:"spec,omitempty"`
+	Status VisitorStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// VisitorList contains a list of Visitor
+type VisitorList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Visitor `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&Visitor{}, &VisitorList{})
+}
@@ -22,16 +22,33 @@ limitations under the License.
 package v1
 
 import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdminTLS) DeepCopyInto(out *AdminTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdminTLS.
+func (in *AdminTLS) DeepCopy() *AdminTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(AdminTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Client) DeepCopyInto(out *Client) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Client.
@@ -52,6 +69,67 @@ func (in *Client) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientAuth) DeepCopyInto(out *ClientAuth) {
+	*out = *in
+	if in.OIDC != nil {
+		in, out := &in.OIDC, &out.OIDC
+		*out = new(OIDCAuth)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientAuth.
+func (in *ClientAuth) DeepCopy() *ClientAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientCommon) DeepCopyInto(out *ClientCommon) {
+	*out = *in
+	in.Token.DeepCopyInto(&out.Token)
+	if in.Auth != nil {
+		in, out := &in.Auth, &out.Auth
+		*out = new(ClientAuth)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(ClientTLS)
+		**out = **in
+	}
+	if in.Transport != nil {
+		in, out := &in.Transport, &out.Transport
+		*out = new(ClientTransport)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutboundProxy != nil {
+		in, out := &in.OutboundProxy, &out.OutboundProxy
+		*out = new(OutboundProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AdminTLS != nil {
+		in, out := &in.AdminTLS, &out.AdminTLS
+		*out = new(AdminTLS)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientCommon.
+func (in *ClientCommon) DeepCopy() *ClientCommon {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientCommon)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientList) DeepCopyInto(out *ClientList) {
 	*out = *in
@@ -84,9 +162,30 @@ func (in *ClientList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientLogging) DeepCopyInto(out *ClientLogging) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientLogging.
+func (in *ClientLogging) DeepCopy() *ClientLogging {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientLogging)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientSpec) DeepCopyInto(out *ClientSpec) {
 	*out = *in
+	in.Common.DeepCopyInto(&out.Common)
+	if in.Logging != nil {
+		in, out := &in.Logging, &out.Logging
+		*out = new(ClientLogging)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientSpec.
@@ -102,6 +201,18 @@ func (in *ClientSpec) DeepCopy() *ClientSpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClientStatus) DeepCopyInto(out *ClientStatus) {
 	*out = *in
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientStatus.
@@ -114,13 +225,253 @@ func (in *ClientStatus) DeepCopy() *ClientStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientTLS) DeepCopyInto(out *ClientTLS) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientTLS.
+func (in *ClientTLS) DeepCopy() *ClientTLS {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientTLS)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientTransport) DeepCopyInto(out *ClientTransport) {
+	*out = *in
+	if in.TCPMux != nil {
+		in, out := &in.TCPMux, &out.TCPMux
+		*out = new(bool)
+		**out = **in
+	}
+	if in.QUIC != nil {
+		in, out := &in.QUIC, &out.QUIC
+		*out = new(QUICOptions)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientTransport.
+func (in *ClientTransport) DeepCopy() *ClientTransport {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientTransport)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Credentials) DeepCopyInto(out *Credentials) {
+	*out = *in
+	in.Password.DeepCopyInto(&out.Password)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Credentials.
+func (in *Credentials) DeepCopy() *Credentials {
+	if in == nil {
+		return nil
+	}
+	out := new(Credentials)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTP2HTTPSPlugin) DeepCopyInto(out *HTTP2HTTPSPlugin) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTP2HTTPSPlugin.
+func (in *HTTP2HTTPSPlugin) DeepCopy() *HTTP2HTTPSPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTP2HTTPSPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPProxy) DeepCopyInto(out *HTTPProxy) {
+	*out = *in
+	if in.CustomDomains != nil {
+		in, out := &in.CustomDomains, &out.CustomDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Locations != nil {
+		in, out := &in.Locations, &out.Locations
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPProxy.
+func (in *HTTPProxy) DeepCopy() *HTTPProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPProxyPlugin) DeepCopyInto(out *HTTPProxyPlugin) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(Credentials)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPProxyPlugin.
+func (in *HTTPProxyPlugin) DeepCopy() *HTTPProxyPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPProxyPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPS2HTTPPlugin) DeepCopyInto(out *HTTPS2HTTPPlugin) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPS2HTTPPlugin.
+func (in *HTTPS2HTTPPlugin) DeepCopy() *HTTPS2HTTPPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPS2HTTPPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HTTPSProxy) DeepCopyInto(out *HTTPSProxy) {
+	*out = *in
+	if in.CustomDomains != nil {
+		in, out := &in.CustomDomains, &out.CustomDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HTTPSProxy.
+func (in *HTTPSProxy) DeepCopy() *HTTPSProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(HTTPSProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OIDCAuth) DeepCopyInto(out *OIDCAuth) {
+	*out = *in
+	in.ClientSecret.DeepCopyInto(&out.ClientSecret)
+	if in.AdditionalEndpointParams != nil {
+		in, out := &in.AdditionalEndpointParams, &out.AdditionalEndpointParams
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OIDCAuth.
+func (in *OIDCAuth) DeepCopy() *OIDCAuth {
+	if in == nil {
+		return nil
+	}
+	out := new(OIDCAuth)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutboundProxy) DeepCopyInto(out *OutboundProxy) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(Credentials)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutboundProxy.
+func (in *OutboundProxy) DeepCopy() *OutboundProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(OutboundProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PluginVolume) DeepCopyInto(out *PluginVolume) {
+	*out = *in
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(corev1.PersistentVolumeClaimVolumeSource)
+		**out = **in
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(corev1.ConfigMapVolumeSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PluginVolume.
+func (in *PluginVolume) DeepCopy() *PluginVolume {
+	if in == nil {
+		return nil
+	}
+	out := new(PluginVolume)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Proxy) DeepCopyInto(out *Proxy) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
-	out.Status = in.Status
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Proxy.
@@ -141,6 +492,37 @@ func (in *Proxy) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyGroup) DeepCopyInto(out *ProxyGroup) {
+	*out = *in
+	in.Key.DeepCopyInto(&out.Key)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyGroup.
+func (in *ProxyGroup) DeepCopy() *ProxyGroup {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyGroup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyHealthCheck) DeepCopyInto(out *ProxyHealthCheck) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyHealthCheck.
+func (in *ProxyHealthCheck) DeepCopy() *ProxyHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxyList) DeepCopyInto(out *ProxyList) {
 	*out = *in
@@ -173,9 +555,114 @@ func (in *ProxyList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ProxyPlugin) DeepCopyInto(out *ProxyPlugin) {
+	*out = *in
+	if in.Socks5 != nil {
+		in, out := &in.Socks5, &out.Socks5
+		*out = new(Socks5Plugin)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPProxy != nil {
+		in, out := &in.HTTPProxy, &out.HTTPProxy
+		*out = new(HTTPProxyPlugin)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.StaticFile != nil {
+		in, out := &in.StaticFile, &out.StaticFile
+		*out = new(StaticFilePlugin)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.UnixDomainSocket != nil {
+		in, out := &in.UnixDomainSocket, &out.UnixDomainSocket
+		*out = new(UnixDomainSocketPlugin)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPS2HTTP != nil {
+		in, out := &in.HTTPS2HTTP, &out.HTTPS2HTTP
+		*out = new(HTTPS2HTTPPlugin)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTP2HTTPS != nil {
+		in, out := &in.HTTP2HTTPS, &out.HTTP2HTTPS
+		*out = new(HTTP2HTTPSPlugin)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyPlugin.
+func (in *ProxyPlugin) DeepCopy() *ProxyPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(ProxyPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxySpec) DeepCopyInto(out *ProxySpec) {
 	*out = *in
+	if in.Plugin != nil {
+		in, out := &in.Plugin, &out.Plugin
+		*out = new(ProxyPlugin)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Group != nil {
+		in, out := &in.Group, &out.Group
+		*out = new(ProxyGroup)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(ProxyHealthCheck)
+		**out = **in
+	}
+	if in.UseEncryption != nil {
+		in, out := &in.UseEncryption, &out.UseEncryption
+		*out = new(bool)
+		**out = **in
+	}
+	if in.TCPProxy != nil {
+		in, out := &in.TCPProxy, &out.TCPProxy
+		*out = new(TCPProxy)
+		**out = **in
+	}
+	if in.UDPProxy != nil {
+		in, out := &in.UDPProxy, &out.UDPProxy
+		*out = new(UDPProxy)
+		**out = **in
+	}
+	if in.HTTPProxy != nil {
+		in, out := &in.HTTPProxy, &out.HTTPProxy
+		*out = new(HTTPProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.HTTPSProxy != nil {
+		in, out := &in.HTTPSProxy, &out.HTTPSProxy
+		*out = new(HTTPSProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.STCPProxy != nil {
+		in, out := &in.STCPProxy, &out.STCPProxy
+		*out = new(STCPProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.XTCPProxy != nil {
+		in, out := &in.XTCPProxy, &out.XTCPProxy
+		*out = new(XTCPProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SUDPProxy != nil {
+		in, out := &in.SUDPProxy, &out.SUDPProxy
+		*out = new(SUDPProxy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TCPMuxProxy != nil {
+		in, out := &in.TCPMuxProxy, &out.TCPMuxProxy
+		*out = new(TCPMuxProxy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxySpec.
@@ -191,6 +678,10 @@ func (in *ProxySpec) DeepCopy() *ProxySpec {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ProxyStatus) DeepCopyInto(out *ProxyStatus) {
 	*out = *in
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ProxyStatus.
@@ -202,3 +693,375 @@ func (in *ProxyStatus) DeepCopy() *ProxyStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QUICOptions) DeepCopyInto(out *QUICOptions) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QUICOptions.
+func (in *QUICOptions) DeepCopy() *QUICOptions {
+	if in == nil {
+		return nil
+	}
+	out := new(QUICOptions)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *STCPProxy) DeepCopyInto(out *STCPProxy) {
+	*out = *in
+	in.SK.DeepCopyInto(&out.SK)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new STCPProxy.
+func (in *STCPProxy) DeepCopy() *STCPProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(STCPProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *STCPVisitor) DeepCopyInto(out *STCPVisitor) {
+	*out = *in
+	in.SK.DeepCopyInto(&out.SK)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new STCPVisitor.
+func (in *STCPVisitor) DeepCopy() *STCPVisitor {
+	if in == nil {
+		return nil
+	}
+	out := new(STCPVisitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SUDPProxy) DeepCopyInto(out *SUDPProxy) {
+	*out = *in
+	in.SK.DeepCopyInto(&out.SK)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SUDPProxy.
+func (in *SUDPProxy) DeepCopy() *SUDPProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(SUDPProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SUDPVisitor) DeepCopyInto(out *SUDPVisitor) {
+	*out = *in
+	in.SK.DeepCopyInto(&out.SK)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SUDPVisitor.
+func (in *SUDPVisitor) DeepCopy() *SUDPVisitor {
+	if in == nil {
+		return nil
+	}
+	out := new(SUDPVisitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Socks5Plugin) DeepCopyInto(out *Socks5Plugin) {
+	*out = *in
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(Credentials)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Socks5Plugin.
+func (in *Socks5Plugin) DeepCopy() *Socks5Plugin {
+	if in == nil {
+		return nil
+	}
+	out := new(Socks5Plugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *StaticFilePlugin) DeepCopyInto(out *StaticFilePlugin) {
+	*out = *in
+	in.Volume.DeepCopyInto(&out.Volume)
+	if in.Credentials != nil {
+		in, out := &in.Credentials, &out.Credentials
+		*out = new(Credentials)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new StaticFilePlugin.
+func (in *StaticFilePlugin) DeepCopy() *StaticFilePlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(StaticFilePlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TCPMuxProxy) DeepCopyInto(out *TCPMuxProxy) {
+	*out = *in
+	if in.CustomDomains != nil {
+		in, out := &in.CustomDomains, &out.CustomDomains
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TCPMuxProxy.
+func (in *TCPMuxProxy) DeepCopy() *TCPMuxProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(TCPMuxProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TCPProxy) DeepCopyInto(out *TCPProxy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TCPProxy.
+func (in *TCPProxy) DeepCopy() *TCPProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(TCPProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenValue) DeepCopyInto(out *TokenValue) {
+	*out = *in
+	if in.ValueFrom != nil {
+		in, out := &in.ValueFrom, &out.ValueFrom
+		*out = new(TokenValueSource)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenValue.
+func (in *TokenValue) DeepCopy() *TokenValue {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenValue)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TokenValueSource) DeepCopyInto(out *TokenValueSource) {
+	*out = *in
+	if in.SecretKeyRef != nil {
+		in, out := &in.SecretKeyRef, &out.SecretKeyRef
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigMapKeyRef != nil {
+		in, out := &in.ConfigMapKeyRef, &out.ConfigMapKeyRef
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TokenValueSource.
+func (in *TokenValueSource) DeepCopy() *TokenValueSource {
+	if in == nil {
+		return nil
+	}
+	out := new(TokenValueSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UDPProxy) DeepCopyInto(out *UDPProxy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UDPProxy.
+func (in *UDPProxy) DeepCopy() *UDPProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(UDPProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *UnixDomainSocketPlugin) DeepCopyInto(out *UnixDomainSocketPlugin) {
+	*out = *in
+	in.Volume.DeepCopyInto(&out.Volume)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new UnixDomainSocketPlugin.
+func (in *UnixDomainSocketPlugin) DeepCopy() *UnixDomainSocketPlugin {
+	if in == nil {
+		return nil
+	}
+	out := new(UnixDomainSocketPlugin)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Visitor) DeepCopyInto(out *Visitor) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	out.Status = in.Status
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Visitor.
+func (in *Visitor) DeepCopy() *Visitor {
+	if in == nil {
+		return nil
+	}
+	out := new(Visitor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *Visitor) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VisitorList) DeepCopyInto(out *VisitorList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Visitor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VisitorList.
+func (in *VisitorList) DeepCopy() *VisitorList {
+	if in == nil {
+		return nil
+	}
+	out := new(VisitorList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *VisitorList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VisitorSpec) DeepCopyInto(out *VisitorSpec) {
+	*out = *in
+	if in.UseEncryption != nil {
+		in, out := &in.UseEncryption, &out.UseEncryption
+		*out = new(bool)
+		**out = **in
+	}
+	if in.STCPVisitor != nil {
+		in, out := &in.STCPVisitor, &out.STCPVisitor
+		*out = new(STCPVisitor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.XTCPVisitor != nil {
+		in, out := &in.XTCPVisitor, &out.XTCPVisitor
+		*out = new(XTCPVisitor)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.SUDPVisitor != nil {
+		in, out := &in.SUDPVisitor, &out.SUDPVisitor
+		*out = new(SUDPVisitor)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VisitorSpec.
+func (in *VisitorSpec) DeepCopy() *VisitorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(VisitorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *VisitorStatus) DeepCopyInto(out *VisitorStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new VisitorStatus.
+func (in *VisitorStatus) DeepCopy() *VisitorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(VisitorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XTCPProxy) DeepCopyInto(out *XTCPProxy) {
+	*out = *in
+	in.SK.DeepCopyInto(&out.SK)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XTCPProxy.
+func (in *XTCPProxy) DeepCopy() *XTCPProxy {
+	if in == nil {
+		return nil
+	}
+	out := new(XTCPProxy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *XTCPVisitor) DeepCopyInto(out *XTCPVisitor) {
+	*out = *in
+	in.SK.DeepCopyInto(&out.SK)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new XTCPVisitor.
+func (in *XTCPVisitor) DeepCopy() *XTCPVisitor {
+	if in == nil {
+		return nil
+	}
+	out := new(XTCPVisitor)
+	in.DeepCopyInto(out)
+	return out
+}
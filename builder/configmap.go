@@ -2,6 +2,8 @@ package builder
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 
 	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
 	"github.com/YoogoC/frpc-operator/gen"
@@ -10,6 +12,9 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// ConfigHashAnnotation is the sha256 of the config in the ConfigMap.
+const ConfigHashAnnotation = "frpc.yoogo.top/config-hash"
+
 type ConfigMapBuilder struct {
 	Name      string
 	Namespace string
@@ -34,7 +39,7 @@ func (builder *ConfigMapBuilder) SetNamespace(namespace string) *ConfigMapBuilde
 	return builder
 }
 
-func (builder *ConfigMapBuilder) Build(ctx context.Context) (*corev1.ConfigMap, error) {
+func (builder *ConfigMapBuilder) BuildConfig(ctx context.Context) (*gen.FrpcConfig, error) {
 	var proxyList frpcv1.ProxyList
 	if err := builder.k8sClient.List(ctx, &proxyList, client.InNamespace(builder.Namespace)); err != nil {
 		return nil, err
@@ -46,20 +51,39 @@ func (builder *ConfigMapBuilder) Build(ctx context.Context) (*corev1.ConfigMap,
 		}
 	}
 
-	configData, err := gen.Gen(builder.k8sClient, builder.frpClient, proxies)
+	var visitorList frpcv1.VisitorList
+	if err := builder.k8sClient.List(ctx, &visitorList, client.InNamespace(builder.Namespace)); err != nil {
+		return nil, err
+	}
+	var visitors []frpcv1.Visitor
+	for _, item := range visitorList.Items {
+		if item.Spec.Client == builder.Name && item.DeletionTimestamp == nil {
+			visitors = append(visitors, item)
+		}
+	}
+
+	return gen.NewConfig(ctx, builder.k8sClient, builder.frpClient, proxies, visitors)
+}
+
+func (builder *ConfigMapBuilder) Build(config *gen.FrpcConfig) (*corev1.ConfigMap, error) {
+	configData, err := config.Gen()
 	if err != nil {
 		return nil, err
 	}
+	hash := sha256.Sum256([]byte(configData))
 	return &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      builder.Name,
 			Namespace: builder.Namespace,
+			Annotations: map[string]string{
+				ConfigHashAnnotation: hex.EncodeToString(hash[:]),
+			},
 			Labels: map[string]string{
 				"app":                            builder.Name,
 				"generated":                      "frpc-operator",
 				builder.Name + "-config-as-code": "yes",
 			},
 		},
-		Data: map[string]string{"config.ini": configData},
+		Data: map[string]string{config.Format.FileName(): configData},
 	}, nil
 }
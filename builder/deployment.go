@@ -1,15 +1,41 @@
 package builder
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"net/url"
+	"path"
+	"strconv"
+
+	"github.com/YoogoC/frpc-operator/gen"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+const (
+	VolumesChecksumAnnotation = "frpc.yoogo.top/volumes-checksum"
+	// EnvsChecksumAnnotation changes with the Secrets frpc reads through its environment.
+	EnvsChecksumAnnotation = "frpc.yoogo.top/envs-checksum"
+	// CommonChecksumAnnotation is set on the pods, it is the checksum of the [common]
+	// section the running frpc was started with. frpc which cannot be stopped through
+	// its admin api gets it on the pod template, so the Deployment replaces the pod.
+	CommonChecksumAnnotation = "frpc.yoogo.top/common-checksum"
+)
+
 type DeployBuilder struct {
 	Name      string
 	Namespace string
 	Image     string
+	Volumes   []gen.Volume
+	Envs      []gen.Env
+	// AdminSecret holds the credentials of the frpc admin API.
+	AdminSecret string
+	Admin       gen.Admin
+	ConfigFile  string
+	// CommonChecksum rolls the pod when the [common] section changes.
+	CommonChecksum string
 }
 
 func NewDeployBuilder() *DeployBuilder {
@@ -31,6 +57,80 @@ func (n *DeployBuilder) SetImage(image string) *DeployBuilder {
 	return n
 }
 
+// SetConfigFile sets the name of the config file, the key of the ConfigMap.
+func (n *DeployBuilder) SetConfigFile(name string) *DeployBuilder {
+	n.ConfigFile = name
+	return n
+}
+
+func (n *DeployBuilder) SetVolumes(volumes []gen.Volume) *DeployBuilder {
+	n.Volumes = volumes
+	return n
+}
+
+// SetEnvs sets the Secret keys the config refers to through placeholders.
+func (n *DeployBuilder) SetEnvs(envs []gen.Env) *DeployBuilder {
+	n.Envs = envs
+	return n
+}
+
+func (n *DeployBuilder) SetAdminSecret(name string) *DeployBuilder {
+	n.AdminSecret = name
+	return n
+}
+
+// SetAdmin takes the admin API settings from the generated config, so the
+// reload URL of the sidecar always points at what frpc listens on.
+func (n *DeployBuilder) SetAdmin(admin gen.Admin) *DeployBuilder {
+	n.Admin = admin
+	return n
+}
+
+// SetCommonChecksum replaces the pod when the [common] section changes, for frpc
+// older than the stop endpoint of the admin api.
+func (n *DeployBuilder) SetCommonChecksum(checksum string) *DeployBuilder {
+	n.CommonChecksum = checksum
+	return n
+}
+
+func (n *DeployBuilder) reloadURL() string {
+	host := n.Admin.AdminAddress
+	if ip := net.ParseIP(host); ip == nil || ip.IsUnspecified() || ip.IsLoopback() {
+		host = "localhost"
+	}
+	scheme := "http"
+	if n.Admin.AdminTLS() {
+		scheme = "https"
+	}
+	return (&url.URL{
+		Scheme: scheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(n.Admin.AdminPort)),
+		Path:   "/api/reload",
+	}).String()
+}
+
+// adminPorts only exposes the admin API when it is reachable from outside the pod.
+func (n *DeployBuilder) adminPorts() []corev1.ContainerPort {
+	if ip := net.ParseIP(n.Admin.AdminAddress); ip != nil && ip.IsLoopback() {
+		return nil
+	}
+	return []corev1.ContainerPort{
+		{Name: "admin", ContainerPort: int32(n.Admin.AdminPort)},
+	}
+}
+
+func (n *DeployBuilder) adminEnv(name string, key string) corev1.EnvVar {
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: n.AdminSecret},
+				Key:                  key,
+			},
+		},
+	}
+}
+
 func (n *DeployBuilder) Build() *appsv1.Deployment {
 	runAsUser := int64(1000)
 	runAsGroup := int64(1000)
@@ -109,20 +209,14 @@ func (n *DeployBuilder) Build() *appsv1.Deployment {
 								},
 								{
 									Name:  "REQ_URL",
-									Value: "http://localhost:7400/api/reload", // TODO
+									Value: n.reloadURL(),
 								},
 								{
 									Name:  "REQ_METHOD",
 									Value: "GET",
 								},
-								{
-									Name:  "REQ_USERNAME",
-									Value: "frpc-admin", // TODO
-								},
-								{
-									Name:  "REQ_PASSWORD",
-									Value: "frpc-password", // TODO
-								},
+								n.adminEnv("REQ_USERNAME", AdminUsernameKey),
+								n.adminEnv("REQ_PASSWORD", AdminPasswordKey),
 								{
 									Name:  "REQ_RETRY_CONNECT",
 									Value: "10", // TODO
@@ -142,10 +236,12 @@ func (n *DeployBuilder) Build() *appsv1.Deployment {
 						{
 							Name:    "frpc",
 							Image:   n.Image,
-							Command: []string{"frpc", "-c", "/frp/config.ini"},
-							Ports: []corev1.ContainerPort{
-								{ContainerPort: int32(4040)},
+							Command: []string{"frpc", "-c", path.Join("/frp", n.ConfigFile)},
+							Env: []corev1.EnvVar{
+								n.adminEnv(gen.AdminUsernameEnv, AdminUsernameKey),
+								n.adminEnv(gen.AdminPasswordEnv, AdminPasswordKey),
 							},
+							Ports: n.adminPorts(),
 							VolumeMounts: []corev1.VolumeMount{
 								{
 									Name:      "config",
@@ -167,6 +263,41 @@ func (n *DeployBuilder) Build() *appsv1.Deployment {
 		},
 	}
 
+	frpc := &deploy.Spec.Template.Spec.Containers[1] // the frpc container
+	checksum := sha256.New()
+	for _, volume := range n.Volumes {
+		checksum.Write([]byte(volume.Name + "=" + volume.Checksum + "\n"))
+		deploy.Spec.Template.Spec.Volumes = append(deploy.Spec.Template.Spec.Volumes, corev1.Volume{
+			Name:         volume.Name,
+			VolumeSource: volume.Source,
+		})
+		frpc.VolumeMounts = append(frpc.VolumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: volume.MountPath,
+			ReadOnly:  true,
+		})
+	}
+	if len(n.Volumes) > 0 {
+		// restart frpc when files it only reads on start, like tls certificates, change
+		deploy.Spec.Template.Annotations[VolumesChecksumAnnotation] = hex.EncodeToString(checksum.Sum(nil))
+	}
+
+	envsChecksum := sha256.New()
+	for _, env := range n.Envs {
+		source := env.Source
+		envsChecksum.Write([]byte(env.Name + "=" + env.Revision + "\n"))
+		frpc.Env = append(frpc.Env, corev1.EnvVar{
+			Name:      env.Name,
+			ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &source},
+		})
+	}
+	if len(n.Envs) > 0 {
+		deploy.Spec.Template.Annotations[EnvsChecksumAnnotation] = hex.EncodeToString(envsChecksum.Sum(nil))
+	}
+	if n.CommonChecksum != "" {
+		deploy.Spec.Template.Annotations[CommonChecksumAnnotation] = n.CommonChecksum
+	}
+
 	return deploy
 }
 
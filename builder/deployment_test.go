@@ -0,0 +1,80 @@
+package builder
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"testing"
+
+	"github.com/YoogoC/frpc-operator/gen"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func findContainer(containers []corev1.Container, name string) *corev1.Container {
+	for i := range containers {
+		if containers[i].Name == name {
+			return &containers[i]
+		}
+	}
+	return nil
+}
+
+func findEnv(env []corev1.EnvVar, name string) string {
+	for _, e := range env {
+		if e.Name == name {
+			return e.Value
+		}
+	}
+	return ""
+}
+
+func TestDeployAdmin(t *testing.T) {
+	tests := []struct {
+		name    string
+		admin   gen.Admin
+		reqURL  string
+		exposed bool
+	}{
+		{"all addresses", gen.Admin{AdminAddress: "0.0.0.0", AdminPort: 7400}, "http://localhost:7400/api/reload", true},
+		{"loopback", gen.Admin{AdminAddress: "127.0.0.1", AdminPort: 7400}, "http://localhost:7400/api/reload", false},
+		{"pod ip", gen.Admin{AdminAddress: "10.0.0.5", AdminPort: 7401}, "http://10.0.0.5:7401/api/reload", true},
+		{"tls", gen.Admin{AdminAddress: "0.0.0.0", AdminPort: 7400, AdminTLSCertFile: "/tls/admin/tls.crt", AdminTLSKeyFile: "/tls/admin/tls.key"}, "https://localhost:7400/api/reload", true},
+		{"tls on loopback", gen.Admin{AdminAddress: "127.0.0.1", AdminPort: 7443, AdminTLSCertFile: "/tls/admin/tls.crt", AdminTLSKeyFile: "/tls/admin/tls.key"}, "https://localhost:7443/api/reload", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			deploy := NewDeployBuilder().SetName("client").SetNamespace("default").SetAdmin(test.admin).Build()
+			containers := deploy.Spec.Template.Spec.Containers
+			sidecar, frpc := findContainer(containers, "config-reload"), findContainer(containers, "frpc")
+			if sidecar == nil || frpc == nil {
+				t.Fatalf("missing containers in %+v", containers)
+			}
+
+			reqURL := findEnv(sidecar.Env, "REQ_URL")
+			if reqURL != test.reqURL {
+				t.Errorf("REQ_URL %s, want %s", reqURL, test.reqURL)
+			}
+			// the sidecar reaches frpc on the port and with the scheme it serves
+			parsed, err := url.Parse(reqURL)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if wantScheme := map[bool]string{false: "http", true: "https"}[test.admin.AdminTLS()]; parsed.Scheme != wantScheme {
+				t.Errorf("REQ_URL scheme %s, want %s", parsed.Scheme, wantScheme)
+			}
+			if _, port, _ := net.SplitHostPort(parsed.Host); port != strconv.Itoa(test.admin.AdminPort) {
+				t.Errorf("REQ_URL port %s, want %d", port, test.admin.AdminPort)
+			}
+
+			if !test.exposed {
+				if len(frpc.Ports) != 0 {
+					t.Errorf("the admin api on %s is exposed: %+v", test.admin.AdminAddress, frpc.Ports)
+				}
+				return
+			}
+			if len(frpc.Ports) != 1 || frpc.Ports[0].ContainerPort != int32(test.admin.AdminPort) {
+				t.Errorf("container ports %+v, want the admin port %d", frpc.Ports, test.admin.AdminPort)
+			}
+		})
+	}
+}
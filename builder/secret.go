@@ -0,0 +1,73 @@
+package builder
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	AdminUsernameKey = "username"
+	AdminPasswordKey = "password"
+)
+
+// AdminSecretName is the name of the Secret holding the admin API credentials of a Client.
+func AdminSecretName(clientName string) string {
+	return clientName + "-admin"
+}
+
+type AdminSecretBuilder struct {
+	Name      string
+	Namespace string
+}
+
+func NewAdminSecretBuilder() *AdminSecretBuilder {
+	return &AdminSecretBuilder{}
+}
+
+func (builder *AdminSecretBuilder) SetName(name string) *AdminSecretBuilder {
+	builder.Name = name
+	return builder
+}
+
+func (builder *AdminSecretBuilder) SetNamespace(namespace string) *AdminSecretBuilder {
+	builder.Namespace = namespace
+	return builder
+}
+
+// Build generates new random credentials on every call.
+func (builder *AdminSecretBuilder) Build() (*corev1.Secret, error) {
+	username, err := randomString(8)
+	if err != nil {
+		return nil, err
+	}
+	password, err := randomString(24)
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      builder.Name,
+			Namespace: builder.Namespace,
+			Labels: map[string]string{
+				"app":       builder.Name,
+				"generated": "frpc-operator",
+			},
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			AdminUsernameKey: "admin-" + username,
+			AdminPasswordKey: password,
+		},
+	}, nil
+}
+
+func randomString(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
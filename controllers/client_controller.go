@@ -18,18 +18,28 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"time"
 
+	"github.com/YoogoC/frpc-operator/admin"
 	"github.com/YoogoC/frpc-operator/builder"
+	"github.com/YoogoC/frpc-operator/gen"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
-	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
 )
@@ -42,12 +52,21 @@ type ClientReconciler struct {
 
 const myFinalizerName = "frpc.yoogo.top/finalizer"
 
+// frpcImage is tagged with the frpVersion of the Client.
+const frpcImage = "fatedier/frpc"
+
+// proxyStatusInterval is how often the proxy status is polled from frpc.
+const proxyStatusInterval = 30 * time.Second
+
 // +kubebuilder:rbac:groups=frpc.yoogo.top,resources=clients,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=frpc.yoogo.top,resources=clients/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=frpc.yoogo.top,resources=clients/finalizers,verbs=update
 
 // +kubebuilder:rbac:groups="apps",resources=deployments,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;watch;create
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups=frpc.yoogo.top,resources=proxies/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=rolebindings,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="rbac.authorization.k8s.io",resources=roles,verbs=get;list;watch;create;update;patch;delete
@@ -94,9 +113,36 @@ func (r *ClientReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, nil
 	}
 	// 2. 如果不是删除,根据client和proxy的定义生成frpc.ini
-	if err := createOrUpdateConfigMap(ctx, r.Client, frpClient); err != nil {
+	oldStatus := frpClient.Status.DeepCopy()
+	frpClient.Status.ObservedGeneration = frpClient.Generation
+	config, configMap, err := createOrUpdateConfigMap(ctx, r.Client, frpClient)
+	if err != nil {
+		var unsupported *gen.UnsupportedError
+		var invalid *gen.ValidationError
+		reason := "RenderFailed"
+		switch {
+		case errors.As(err, &unsupported):
+			reason = "UnsupportedFeature"
+			r.setCondition(frpClient, frpcv1.ClientFrpVersionSupported, metav1.ConditionFalse, reason, err.Error())
+		case errors.As(err, &invalid):
+			reason = "InvalidValue"
+		}
+		r.setCondition(frpClient, frpcv1.ClientConfigRendered, metav1.ConditionFalse, reason, err.Error())
+		r.setCondition(frpClient, frpcv1.ClientReady, metav1.ConditionFalse, "ConfigNotRendered", "the config could not be rendered")
+		if err := r.updateStatus(ctx, frpClient, oldStatus); err != nil {
+			return ctrl.Result{}, err
+		}
+		if isConfigError(err) {
+			// nothing to retry until the Client or its proxies change
+			return ctrl.Result{}, nil
+		}
 		return ctrl.Result{}, err
 	}
+	frpClient.Status.Groups = config.Groups()
+	frpClient.Status.Proxies = config.ProxyCount()
+	frpClient.Status.ConfigHash = configMap.Annotations[builder.ConfigHashAnnotation]
+	r.setCondition(frpClient, frpcv1.ClientFrpVersionSupported, metav1.ConditionTrue, "Supported", "frpc "+config.Version.String()+" supports the config")
+	r.setCondition(frpClient, frpcv1.ClientConfigRendered, metav1.ConditionTrue, "Rendered", "the config is in ConfigMap "+configMap.Name)
 
 	serviceAccountName := "frpc-config-reload"
 	roleName := "frpc-config-reload"
@@ -105,40 +151,375 @@ func (r *ClientReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 		return ctrl.Result{}, err
 	}
 
+	if err := tryCreateAdminSecret(ctx, r.Client, r.Scheme, frpClient); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// frpc before the stop endpoint is restarted by replacing its pod
+	rolloutChecksum := ""
+	if !config.Version.Supports(gen.FeatureAdminStop) {
+		rolloutChecksum = config.CommonChecksum()
+	}
+
 	// 4. 尝试找到同名的deploy,找不到就创建,找到就更新
 	deploy := builder.NewDeployBuilder().
 		SetName(req.Name).
-		SetImage("fatedier/frpc:v0.44.0"). // TODO
+		SetImage(frpcImage + ":" + config.Version.String()).
+		SetConfigFile(config.Format.FileName()).
 		SetNamespace(req.Namespace).
+		SetVolumes(config.Volumes).
+		SetEnvs(config.Envs).
+		SetAdminSecret(builder.AdminSecretName(req.Name)).
+		SetAdmin(config.Common.Admin).
+		SetCommonChecksum(rolloutChecksum).
 		Build()
 
-	oldDeploy := new(appsv1.Deployment)
-	if err := r.Client.Get(ctx, req.NamespacedName, oldDeploy); err != nil {
-		if apierrors.IsNotFound(err) {
-			if err := r.Client.Create(ctx, deploy); err != nil {
-				return ctrl.Result{}, err
-			} else {
-				return ctrl.Result{}, nil
+	// metadata is merged, the deployment controller keeps its own annotations there
+	current := &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: deploy.Name, Namespace: deploy.Namespace}}
+	if _, err := controllerutil.CreateOrUpdate(ctx, r.Client, current, func() error {
+		if current.Labels == nil {
+			current.Labels = map[string]string{}
+		}
+		for k, v := range deploy.Labels {
+			current.Labels[k] = v
+		}
+		if current.Annotations == nil {
+			current.Annotations = map[string]string{}
+		}
+		for k, v := range deploy.Annotations {
+			current.Annotations[k] = v
+		}
+		current.Spec = deploy.Spec
+		return controllerutil.SetControllerReference(frpClient, current, r.Scheme)
+	}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	available := deploymentAvailable(current)
+	r.setCondition(frpClient, frpcv1.ClientDeploymentAvailable, available.Status, available.Reason, available.Message)
+	applied, err := false, error(nil)
+	if available.Status == metav1.ConditionTrue {
+		applied, err = r.configApplied(ctx, frpClient, config)
+	}
+	ready := readyCondition(available, applied, err)
+	r.setCondition(frpClient, frpcv1.ClientReady, ready.Status, ready.Reason, ready.Message)
+	if err := r.updateStatus(ctx, frpClient, oldStatus); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	result, err := r.restartChangedFrpc(ctx, frpClient, config)
+	if err != nil {
+		return result, err
+	}
+	if err := r.updateProxyStatuses(ctx, frpClient, config); err != nil {
+		// frpc may still be starting, the next poll tries again
+		log.FromContext(ctx).Info("cannot read the proxy status from frpc", "error", err.Error())
+	}
+	if result.RequeueAfter == 0 || result.RequeueAfter > proxyStatusInterval {
+		result.RequeueAfter = proxyStatusInterval
+	}
+	return result, nil
+}
+
+// updateProxyStatuses polls the admin api of frpc and writes the state of each proxy
+// into its status, while frpc cannot be asked the proxies are Pending or Unknown.
+func (r *ClientReconciler) updateProxyStatuses(ctx context.Context, frpClient *frpcv1.Client, config *gen.FrpcConfig) error {
+	var statuses map[string]admin.ProxyStatus
+	var unreachable error
+	phase, reason := "", ""
+	adminClient, err := r.runningAdminClient(ctx, frpClient, config)
+	switch {
+	case errors.Is(err, errFrpcNotRunning):
+		phase, reason = "Pending", "FrpcNotRunning"
+	case errors.Is(err, errAdminLoopback):
+		phase, reason = "Unknown", "AdminAPILoopback"
+	case err != nil:
+		return err
+	default:
+		if statuses, unreachable = adminClient.Status(ctx); unreachable != nil {
+			phase, reason = "Unknown", "AdminAPIUnreachable"
+		}
+	}
+	var proxyList frpcv1.ProxyList
+	if err := r.List(ctx, &proxyList, client.InNamespace(frpClient.Namespace)); err != nil {
+		return err
+	}
+	now := metav1.Now()
+	for i := range proxyList.Items {
+		proxy := &proxyList.Items[i]
+		if proxy.Spec.Client != frpClient.Name || proxy.DeletionTimestamp != nil {
+			continue
+		}
+		var status frpcv1.ProxyStatus
+		if phase != "" {
+			status = unreportedProxyStatus(proxy.Status, phase, reason, now)
+		} else {
+			var reported *admin.ProxyStatus
+			if s, ok := statuses[proxy.Name]; ok {
+				reported = &s
+			}
+			status = newProxyStatus(proxy.Status, reported, now)
+		}
+		if reflect.DeepEqual(status, proxy.Status) {
+			continue
+		}
+		proxy.Status = status
+		if err := r.Status().Update(ctx, proxy); err != nil {
+			return err
+		}
+	}
+	return unreachable
+}
+
+var (
+	errFrpcNotRunning = errors.New("no frpc pod is running")
+	errAdminLoopback  = errors.New("the admin api of frpc only listens on localhost")
+)
+
+// runningAdminClient returns a client for the admin api of a running frpc pod.
+func (r *ClientReconciler) runningAdminClient(ctx context.Context, frpClient *frpcv1.Client, config *gen.FrpcConfig) (*admin.Client, error) {
+	if ip := net.ParseIP(config.Common.AdminAddress); ip != nil && ip.IsLoopback() {
+		return nil, errAdminLoopback
+	}
+	pods, err := r.listFrpcPods(ctx, frpClient)
+	if err != nil {
+		return nil, err
+	}
+	for i := range pods {
+		if pods[i].DeletionTimestamp == nil && pods[i].Status.Phase == corev1.PodRunning && pods[i].Status.PodIP != "" {
+			return r.adminClient(ctx, frpClient, config, &pods[i])
+		}
+	}
+	return nil, errFrpcNotRunning
+}
+
+// configApplied reports whether frpc reads the config currently in the ConfigMap,
+// the sidecar copies it over with a delay.
+func (r *ClientReconciler) configApplied(ctx context.Context, frpClient *frpcv1.Client, config *gen.FrpcConfig) (bool, error) {
+	adminClient, err := r.runningAdminClient(ctx, frpClient, config)
+	if err != nil {
+		return false, err
+	}
+	data, err := config.Gen()
+	if err != nil {
+		return false, err
+	}
+	return adminClient.ConfigSynced(ctx, data, renderConfig(config, data, adminClient))
+}
+
+// readyCondition derives the Ready condition from the availability of the Deployment
+// and whether frpc was seen with the current config, err is why that could not be checked.
+func readyCondition(available metav1.Condition, applied bool, err error) metav1.Condition {
+	switch {
+	case available.Status != metav1.ConditionTrue:
+		return metav1.Condition{Status: metav1.ConditionFalse, Reason: "DeploymentUnavailable", Message: available.Message}
+	case err != nil:
+		return metav1.Condition{Status: metav1.ConditionUnknown, Reason: "ConfigUnverified", Message: "cannot read the config from frpc: " + err.Error()}
+	case !applied:
+		return metav1.Condition{Status: metav1.ConditionFalse, Reason: "ConfigPending", Message: "frpc has not read the current config yet"}
+	default:
+		return metav1.Condition{Status: metav1.ConditionTrue, Reason: "Ready", Message: "frpc reads the current config"}
+	}
+}
+
+func (r *ClientReconciler) listFrpcPods(ctx context.Context, frpClient *frpcv1.Client) ([]corev1.Pod, error) {
+	var podList corev1.PodList
+	labels := builder.NewDeployBuilder().SetName(frpClient.Name).BuildLabels()
+	if err := r.List(ctx, &podList, client.InNamespace(frpClient.Namespace), client.MatchingLabels(labels)); err != nil {
+		return nil, err
+	}
+	return podList.Items, nil
+}
+
+func (r *ClientReconciler) setCondition(frpClient *frpcv1.Client, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(&frpClient.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: frpClient.Generation,
+	})
+}
+
+// updateStatus only writes the status when it changed, the Client is watched.
+func (r *ClientReconciler) updateStatus(ctx context.Context, frpClient *frpcv1.Client, oldStatus *frpcv1.ClientStatus) error {
+	if reflect.DeepEqual(oldStatus, &frpClient.Status) {
+		return nil
+	}
+	return r.Status().Update(ctx, frpClient)
+}
+
+// deploymentAvailable derives the DeploymentAvailable condition from the Available
+// condition of the Deployment.
+func deploymentAvailable(deploy *appsv1.Deployment) metav1.Condition {
+	for _, condition := range deploy.Status.Conditions {
+		if condition.Type == appsv1.DeploymentAvailable {
+			reason := condition.Reason
+			if reason == "" {
+				reason = "Unknown"
 			}
+			return metav1.Condition{Status: metav1.ConditionStatus(condition.Status), Reason: reason, Message: condition.Message}
 		}
+	}
+	return metav1.Condition{Status: metav1.ConditionFalse, Reason: "Creating", Message: "the frpc deployment has no Available condition yet"}
+}
+
+// restartChangedFrpc applies a changed [common] section, e.g. a new log_level, to the
+// running pods. frpc only reloads proxies and visitors, so once the sidecar has written
+// the new config, frpc is stopped through its admin api and the kubelet restarts the
+// container in place, the pod is kept. Older frpc has its pod replaced by the Deployment.
+func (r *ClientReconciler) restartChangedFrpc(ctx context.Context, frpClient *frpcv1.Client, config *gen.FrpcConfig) (ctrl.Result, error) {
+	if !config.Version.Supports(gen.FeatureAdminStop) {
+		return ctrl.Result{}, nil
+	}
+	logger := log.FromContext(ctx)
+	checksum := config.CommonChecksum()
+	pods, err := r.listFrpcPods(ctx, frpClient)
+	if err != nil {
 		return ctrl.Result{}, err
-	} else {
-		err := r.Client.Update(ctx, deploy)
-		if err != nil {
+	}
+	pending := false
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil || pod.Annotations[builder.CommonChecksumAnnotation] == checksum {
+			continue
+		}
+		if _, ok := pod.Annotations[builder.CommonChecksumAnnotation]; ok {
+			if pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+				pending = true
+				continue
+			}
+			if ip := net.ParseIP(config.Common.AdminAddress); ip != nil && ip.IsLoopback() {
+				logger.Info("frpc admin api only listens on localhost, delete the pod to apply the [common] section", "pod", pod.Name)
+				continue
+			}
+			adminClient, err := r.adminClient(ctx, frpClient, config, pod)
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			data, err := config.Gen()
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			synced, err := adminClient.ConfigSynced(ctx, data, renderConfig(config, data, adminClient))
+			if err != nil {
+				return ctrl.Result{}, err
+			}
+			if !synced {
+				pending = true
+				continue
+			}
+			if err := adminClient.Stop(ctx); err != nil {
+				return ctrl.Result{}, err
+			}
+			logger.Info("restarted frpc to apply the [common] section", "pod", pod.Name)
+		}
+		// a new pod started with the current config
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[builder.CommonChecksumAnnotation] = checksum
+		if err := r.Patch(ctx, pod, patch); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
-
+	if pending {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
 	return ctrl.Result{}, nil
 }
 
+// renderConfig fills in the config the way frpc does, with the values of its environment.
+func renderConfig(config *gen.FrpcConfig, data string, adminClient *admin.Client) string {
+	return config.Render(data, map[string]string{
+		gen.AdminUsernameEnv: adminClient.Username,
+		gen.AdminPasswordEnv: adminClient.Password,
+	})
+}
+
+func (r *ClientReconciler) adminClient(ctx context.Context, frpClient *frpcv1.Client, config *gen.FrpcConfig, pod *corev1.Pod) (*admin.Client, error) {
+	secret := new(corev1.Secret)
+	if err := r.Get(ctx, types.NamespacedName{Name: builder.AdminSecretName(frpClient.Name), Namespace: frpClient.Namespace}, secret); err != nil {
+		return nil, err
+	}
+	scheme := "http"
+	if config.Common.AdminTLS() {
+		scheme = "https"
+	}
+	url := scheme + "://" + net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(config.Common.AdminPort))
+	return admin.NewClient(url, string(secret.Data[builder.AdminUsernameKey]), string(secret.Data[builder.AdminPasswordKey])), nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ClientReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&frpcv1.Client{}).
+		Owns(&appsv1.Deployment{}).
+		Watches(&source.Kind{Type: &frpcv1.Proxy{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.(*frpcv1.Proxy).Spec.Client, Namespace: obj.GetNamespace()}}}
+		})).
+		Watches(&source.Kind{Type: &frpcv1.Visitor{}}, handler.EnqueueRequestsFromMapFunc(func(obj client.Object) []reconcile.Request {
+			return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: obj.(*frpcv1.Visitor).Spec.Client, Namespace: obj.GetNamespace()}}}
+		})).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(r.findClientsForSecret)).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.findClientsForConfigMap)).
 		Complete(r)
 }
 
+// findClientsForSecret maps a Secret to the Clients whose config, including the config
+// of their proxies and visitors, reads from it.
+func (r *ClientReconciler) findClientsForSecret(secret client.Object) []reconcile.Request {
+	ctx := context.Background()
+	var requests []reconcile.Request
+	enqueue := func(name string) {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: name, Namespace: secret.GetNamespace()}})
+	}
+	var clientList frpcv1.ClientList
+	if err := r.List(ctx, &clientList, client.InNamespace(secret.GetNamespace())); err != nil {
+		return nil
+	}
+	for _, item := range clientList.Items {
+		if containsString(clientSecretNames(&item), secret.GetName()) {
+			enqueue(item.Name)
+		}
+	}
+	var proxyList frpcv1.ProxyList
+	if err := r.List(ctx, &proxyList, client.InNamespace(secret.GetNamespace())); err != nil {
+		return nil
+	}
+	for _, item := range proxyList.Items {
+		if containsString(proxySecretNames(&item), secret.GetName()) {
+			enqueue(item.Spec.Client)
+		}
+	}
+	var visitorList frpcv1.VisitorList
+	if err := r.List(ctx, &visitorList, client.InNamespace(secret.GetNamespace())); err != nil {
+		return nil
+	}
+	for _, item := range visitorList.Items {
+		if containsString(visitorSecretNames(&item), secret.GetName()) {
+			enqueue(item.Spec.Client)
+		}
+	}
+	return requests
+}
+
+// findClientsForConfigMap maps a ConfigMap to the Clients whose config reads from it.
+func (r *ClientReconciler) findClientsForConfigMap(configMap client.Object) []reconcile.Request {
+	var clientList frpcv1.ClientList
+	if err := r.List(context.Background(), &clientList, client.InNamespace(configMap.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, item := range clientList.Items {
+		if containsString(clientConfigMapNames(&item), configMap.GetName()) {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: item.Name, Namespace: configMap.GetNamespace()}})
+		}
+	}
+	return requests
+}
+
 func (r *ClientReconciler) deleteExternalResources(ctx context.Context, nn types.NamespacedName) error {
 	err := r.Client.Delete(ctx, &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
@@ -0,0 +1,405 @@
+package controllers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	"github.com/YoogoC/frpc-operator/builder"
+	"github.com/YoogoC/frpc-operator/gen"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestDeploymentAvailable(t *testing.T) {
+	creating := deploymentAvailable(&appsv1.Deployment{})
+	if creating.Status != metav1.ConditionFalse || creating.Reason != "Creating" {
+		t.Errorf("deployment without conditions: %+v", creating)
+	}
+
+	deploy := &appsv1.Deployment{}
+	deploy.Status.Conditions = []appsv1.DeploymentCondition{
+		{Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: "NewReplicaSetAvailable"},
+		{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionFalse, Reason: "MinimumReplicasUnavailable", Message: "no replicas"},
+	}
+	unavailable := deploymentAvailable(deploy)
+	if unavailable.Status != metav1.ConditionFalse || unavailable.Reason != "MinimumReplicasUnavailable" || unavailable.Message != "no replicas" {
+		t.Errorf("unavailable deployment: %+v", unavailable)
+	}
+
+	deploy.Status.Conditions[1] = appsv1.DeploymentCondition{Type: appsv1.DeploymentAvailable, Status: corev1.ConditionTrue}
+	available := deploymentAvailable(deploy)
+	if available.Status != metav1.ConditionTrue || available.Reason != "Unknown" {
+		t.Errorf("available deployment without a reason: %+v", available)
+	}
+}
+
+func TestSetCondition(t *testing.T) {
+	r := &ClientReconciler{}
+	frpClient := newTestClient()
+	frpClient.Generation = 1
+	r.setCondition(frpClient, frpcv1.ClientReady, metav1.ConditionFalse, "ConfigPending", "pending")
+	condition := meta.FindStatusCondition(frpClient.Status.Conditions, frpcv1.ClientReady)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "ConfigPending" || condition.ObservedGeneration != 1 {
+		t.Fatalf("unexpected condition %+v", condition)
+	}
+	transition := metav1.NewTime(condition.LastTransitionTime.Add(-time.Hour))
+	condition.LastTransitionTime = transition
+
+	// the same status keeps the transition time
+	frpClient.Generation = 2
+	r.setCondition(frpClient, frpcv1.ClientReady, metav1.ConditionFalse, "DeploymentUnavailable", "unavailable")
+	condition = meta.FindStatusCondition(frpClient.Status.Conditions, frpcv1.ClientReady)
+	if len(frpClient.Status.Conditions) != 1 || condition.Reason != "DeploymentUnavailable" || condition.ObservedGeneration != 2 {
+		t.Fatalf("condition not updated in place: %+v", frpClient.Status.Conditions)
+	}
+	if !condition.LastTransitionTime.Equal(&transition) {
+		t.Error("the transition time changed without a change of status")
+	}
+
+	r.setCondition(frpClient, frpcv1.ClientReady, metav1.ConditionTrue, "Ready", "ready")
+	condition = meta.FindStatusCondition(frpClient.Status.Conditions, frpcv1.ClientReady)
+	if condition.LastTransitionTime.Equal(&transition) {
+		t.Error("the transition time was kept for a change of status")
+	}
+}
+
+func TestReadyCondition(t *testing.T) {
+	available := metav1.Condition{Status: metav1.ConditionTrue, Reason: "MinimumReplicasAvailable"}
+	unavailable := metav1.Condition{Status: metav1.ConditionFalse, Reason: "Creating", Message: "creating"}
+	tests := []struct {
+		name      string
+		available metav1.Condition
+		applied   bool
+		err       error
+		status    metav1.ConditionStatus
+		reason    string
+	}{
+		{"unavailable", unavailable, false, nil, metav1.ConditionFalse, "DeploymentUnavailable"},
+		{"unavailable with an error", unavailable, false, errFrpcNotRunning, metav1.ConditionFalse, "DeploymentUnavailable"},
+		{"unreachable", available, false, errAdminLoopback, metav1.ConditionUnknown, "ConfigUnverified"},
+		{"pending", available, false, nil, metav1.ConditionFalse, "ConfigPending"},
+		{"ready", available, true, nil, metav1.ConditionTrue, "Ready"},
+	}
+	for _, test := range tests {
+		condition := readyCondition(test.available, test.applied, test.err)
+		if condition.Status != test.status || condition.Reason != test.reason {
+			t.Errorf("%s: got %s/%s, want %s/%s", test.name, condition.Status, condition.Reason, test.status, test.reason)
+		}
+	}
+}
+
+func TestReconcileInvalidValue(t *testing.T) {
+	injected := newTestProxy("web", 6000)
+	injected.Spec.LocalAddr = "web\n[common]"
+	twoTypes := newTestProxy("web", 6000)
+	twoTypes.Spec.UDPProxy = &frpcv1.UDPProxy{RemotePort: "6000"}
+	noDomains := newTestProxy("web", 6000)
+	noDomains.Spec.TCPProxy, noDomains.Spec.HTTPProxy = nil, &frpcv1.HTTPProxy{}
+	for name, proxy := range map[string]*frpcv1.Proxy{"injected": injected, "two types": twoTypes, "no domains": noDomains} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			frpClient := newTestClient()
+			k8sClient := newFakeClient(t, frpClient, proxy)
+			r := &ClientReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+			result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(frpClient)})
+			if err != nil {
+				t.Fatalf("an invalid value is retried: %v", err)
+			}
+			if result.Requeue || result.RequeueAfter != 0 {
+				t.Errorf("an invalid value is requeued: %+v", result)
+			}
+			updated := &frpcv1.Client{}
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(frpClient), updated); err != nil {
+				t.Fatal(err)
+			}
+			rendered := meta.FindStatusCondition(updated.Status.Conditions, frpcv1.ClientConfigRendered)
+			if rendered == nil || rendered.Status != metav1.ConditionFalse || rendered.Reason != "InvalidValue" {
+				t.Errorf("unexpected ConfigRendered condition %+v", rendered)
+			}
+			ready := meta.FindStatusCondition(updated.Status.Conditions, frpcv1.ClientReady)
+			if ready == nil || ready.Status != metav1.ConditionFalse {
+				t.Errorf("unexpected Ready condition %+v", ready)
+			}
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(frpClient), &corev1.ConfigMap{}); !apierrors.IsNotFound(err) {
+				t.Errorf("a ConfigMap was written for an invalid config: %v", err)
+			}
+
+			// the proxy reconciler leaves invalid values to the Client
+			proxyReconciler := &ProxyReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			if _, err := proxyReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(proxy)}); err != nil {
+				t.Errorf("the proxy reconciler retries an invalid value: %v", err)
+			}
+		})
+	}
+}
+
+func TestReconcileUnsupportedFeature(t *testing.T) {
+	ctx := context.Background()
+	frpClient := newTestClient()
+	frpClient.Spec.FrpVersion = "v0.47.0"
+	frpClient.Spec.Common.NatHoleSTUNServer = "stun.example.com:3478"
+	k8sClient := newFakeClient(t, frpClient)
+	r := &ClientReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+	result, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(frpClient)})
+	if err != nil {
+		t.Fatalf("an unsupported feature is retried: %v", err)
+	}
+	if result.Requeue || result.RequeueAfter != 0 {
+		t.Errorf("an unsupported feature is requeued: %+v", result)
+	}
+	updated := &frpcv1.Client{}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(frpClient), updated); err != nil {
+		t.Fatal(err)
+	}
+	for _, conditionType := range []string{frpcv1.ClientFrpVersionSupported, frpcv1.ClientConfigRendered} {
+		condition := meta.FindStatusCondition(updated.Status.Conditions, conditionType)
+		if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "UnsupportedFeature" {
+			t.Errorf("unexpected %s condition %+v", conditionType, condition)
+		}
+	}
+	supported := meta.FindStatusCondition(updated.Status.Conditions, frpcv1.ClientFrpVersionSupported)
+	if supported != nil && !strings.Contains(supported.Message, "nat_hole_stun_server") {
+		t.Errorf("the message does not name the feature: %s", supported.Message)
+	}
+
+	// a version with the feature clears the condition
+	updated.Spec.FrpVersion = "v0.51.0"
+	if err := k8sClient.Update(ctx, updated); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(frpClient)}); err != nil {
+		t.Fatal(err)
+	}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(frpClient), updated); err != nil {
+		t.Fatal(err)
+	}
+	if !meta.IsStatusConditionTrue(updated.Status.Conditions, frpcv1.ClientFrpVersionSupported) {
+		t.Errorf("FrpVersionSupported is not True for v0.51.0: %+v", updated.Status.Conditions)
+	}
+}
+
+// frpcAdmin is a stub of the frpc admin api, it answers GET requests by path and
+// other methods by method and path, e.g. "POST /api/stop".
+type frpcAdmin struct {
+	port     int
+	mu       sync.Mutex
+	requests []string
+}
+
+func newFrpcAdmin(t *testing.T, status int, routes map[string]string) *frpcAdmin {
+	stub := &frpcAdmin{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := r.URL.Path
+		if r.Method != http.MethodGet {
+			route = r.Method + " " + route
+		}
+		stub.mu.Lock()
+		stub.requests = append(stub.requests, route)
+		stub.mu.Unlock()
+		body, ok := routes[route]
+		if user, pwd, authOK := r.BasicAuth(); !authOK || user != "admin" || pwd != "secret" || !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	serverURL, err := url.Parse(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stub.port, err = strconv.Atoi(serverURL.Port()); err != nil {
+		t.Fatal(err)
+	}
+	return stub
+}
+
+func (stub *frpcAdmin) called(route string) bool {
+	stub.mu.Lock()
+	defer stub.mu.Unlock()
+	return containsString(stub.requests, route)
+}
+
+func newAdminSecret() *corev1.Secret {
+	secret := &corev1.Secret{Data: map[string][]byte{builder.AdminUsernameKey: []byte("admin"), builder.AdminPasswordKey: []byte("secret")}}
+	secret.Name, secret.Namespace = builder.AdminSecretName("client"), "default"
+	return secret
+}
+
+func newFrpcPod(phase corev1.PodPhase) *corev1.Pod {
+	pod := &corev1.Pod{}
+	pod.Name, pod.Namespace = "client-0", "default"
+	pod.Labels = builder.NewDeployBuilder().SetName("client").BuildLabels()
+	pod.Status.Phase = phase
+	if phase == corev1.PodRunning {
+		pod.Status.PodIP = "127.0.0.1"
+	}
+	return pod
+}
+
+func TestUpdateProxyStatuses(t *testing.T) {
+	statusBody := `{"tcp": [{"name": "web", "type": "tcp", "status": "running", "err": "", "local_addr": "web:80", "plugin": "", "remote_addr": ":6000"}]}`
+	tests := []struct {
+		name      string
+		adminAddr string
+		pod       *corev1.Pod
+		status    int
+		phase     string
+		reason    string
+		remote    string
+		err       bool
+	}{
+		{"running", "", newFrpcPod(corev1.PodRunning), http.StatusOK, "Running", "", ":6000", false},
+		{"no pod", "", nil, http.StatusOK, "Pending", "FrpcNotRunning", "", false},
+		{"pod not running", "", newFrpcPod(corev1.PodPending), http.StatusOK, "Pending", "FrpcNotRunning", "", false},
+		{"loopback", "127.0.0.1", newFrpcPod(corev1.PodRunning), http.StatusOK, "Unknown", "AdminAPILoopback", "", false},
+		{"unreachable", "", newFrpcPod(corev1.PodRunning), http.StatusInternalServerError, "Unknown", "AdminAPIUnreachable", "", true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			frpClient := newTestClient()
+			frpClient.Spec.Common.AdminAddr = test.adminAddr
+			frpClient.Spec.Common.AdminPort = newFrpcAdmin(t, test.status, map[string]string{"/api/status": statusBody}).port
+			other := newTestProxy("other", 6001)
+			other.Spec.Client = "other"
+			objs := []client.Object{frpClient, newAdminSecret(), newTestProxy("web", 6000), other}
+			if test.pod != nil {
+				objs = append(objs, test.pod)
+			}
+			k8sClient := newFakeClient(t, objs...)
+			r := &ClientReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+			config, err := builder.NewConfigMapBuilder(k8sClient, frpClient).SetName("client").SetNamespace("default").BuildConfig(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := r.updateProxyStatuses(ctx, frpClient, config); (err != nil) != test.err {
+				t.Fatalf("got error %v, want an error: %v", err, test.err)
+			}
+			proxy := &frpcv1.Proxy{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Name: "web", Namespace: "default"}, proxy); err != nil {
+				t.Fatal(err)
+			}
+			if proxy.Status.Phase != test.phase || proxy.Status.Reason != test.reason || proxy.Status.RemoteAddr != test.remote || proxy.Status.LastTransitionTime == nil {
+				t.Errorf("unexpected status %+v", proxy.Status)
+			}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Name: "other", Namespace: "default"}, other); err != nil {
+				t.Fatal(err)
+			}
+			if other.Status.Phase != "" {
+				t.Errorf("the proxy of another client got the status %+v", other.Status)
+			}
+		})
+	}
+}
+
+func TestRestartChangedFrpc(t *testing.T) {
+	tests := []struct {
+		name     string
+		version  string
+		synced   bool
+		stopped  bool
+		patched  bool
+		requeued bool
+	}{
+		{"stop endpoint", "v0.52.0", true, true, true, false},
+		{"config not written yet", "v0.52.0", false, false, false, true},
+		// the Deployment replaces the pod, see TestReconcileRolloutWithoutStop
+		{"no stop endpoint", "v0.44.0", true, false, false, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			frpClient := newTestClient()
+			frpClient.Spec.FrpVersion = test.version
+			pod := newFrpcPod(corev1.PodRunning)
+			pod.Annotations = map[string]string{builder.CommonChecksumAnnotation: "old"}
+			k8sClient := newFakeClient(t, newAdminSecret(), pod)
+
+			routes := map[string]string{"/api/config": "[common]\nserver_addr = old\n"}
+			if test.version != "v0.44.0" {
+				routes["POST /api/stop"] = ""
+			}
+			stub := newFrpcAdmin(t, http.StatusOK, routes)
+			frpClient.Spec.Common.AdminPort = stub.port
+			config, err := builder.NewConfigMapBuilder(k8sClient, frpClient).SetName("client").SetNamespace("default").BuildConfig(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.synced {
+				data, err := config.Gen()
+				if err != nil {
+					t.Fatal(err)
+				}
+				routes["/api/config"] = config.Render(data, map[string]string{gen.AdminUsernameEnv: "admin", gen.AdminPasswordEnv: "secret"})
+			}
+			r := &ClientReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+
+			result, err := r.restartChangedFrpc(ctx, frpClient, config)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if requeued := result.RequeueAfter != 0; requeued != test.requeued {
+				t.Errorf("requeued %v, want %v", requeued, test.requeued)
+			}
+			if stopped := stub.called("POST /api/stop"); stopped != test.stopped {
+				t.Errorf("stopped %v, want %v", stopped, test.stopped)
+			}
+			updated := &corev1.Pod{}
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(pod), updated); err != nil {
+				t.Fatal(err)
+			}
+			if patched := updated.Annotations[builder.CommonChecksumAnnotation] == config.CommonChecksum(); patched != test.patched {
+				t.Errorf("pod annotated with the current checksum %v, want %v", patched, test.patched)
+			}
+		})
+	}
+}
+
+func TestReconcileRolloutWithoutStop(t *testing.T) {
+	for _, test := range []struct {
+		version string
+		rollout bool
+	}{
+		{"v0.44.0", true},
+		{"v0.52.0", false},
+	} {
+		ctx := context.Background()
+		frpClient := newTestClient()
+		frpClient.Spec.FrpVersion = test.version
+		k8sClient := newFakeClient(t, frpClient)
+		r := &ClientReconciler{Client: k8sClient, Scheme: k8sClient.Scheme()}
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(frpClient)}); err != nil {
+			t.Fatalf("%s: %v", test.version, err)
+		}
+		deploy := &appsv1.Deployment{}
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(frpClient), deploy); err != nil {
+			t.Fatal(err)
+		}
+		config, err := builder.NewConfigMapBuilder(k8sClient, frpClient).SetName("client").SetNamespace("default").BuildConfig(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checksum, ok := deploy.Spec.Template.Annotations[builder.CommonChecksumAnnotation]
+		if ok != test.rollout || ok && checksum != config.CommonChecksum() {
+			t.Errorf("%s: pod template checksum %q, want it set %v", test.version, checksum, test.rollout)
+		}
+	}
+}
@@ -2,31 +2,75 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"reflect"
 
+	"github.com/YoogoC/frpc-operator/admin"
 	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
 	"github.com/YoogoC/frpc-operator/builder"
+	"github.com/YoogoC/frpc-operator/gen"
 	corev1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
-func createOrUpdateConfigMap(ctx context.Context, k8sClient client.Client, frpClient *frpcv1.Client) error {
-	configMap, err := builder.NewConfigMapBuilder(k8sClient, frpClient).SetName(frpClient.Name).SetNamespace(frpClient.Namespace).Build(ctx)
+// isConfigError reports whether the config cannot be rendered from the Client and its
+// proxies as they are, retrying does not help until one of them changes.
+func isConfigError(err error) bool {
+	var unsupported *gen.UnsupportedError
+	var invalid *gen.ValidationError
+	return errors.As(err, &unsupported) || errors.As(err, &invalid)
+}
+
+func createOrUpdateConfigMap(ctx context.Context, k8sClient client.Client, frpClient *frpcv1.Client) (*gen.FrpcConfig, *corev1.ConfigMap, error) {
+	cb := builder.NewConfigMapBuilder(k8sClient, frpClient).SetName(frpClient.Name).SetNamespace(frpClient.Namespace)
+	config, err := cb.BuildConfig(ctx)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	if err := k8sClient.Get(ctx, client.ObjectKey{Name: frpClient.Name, Namespace: frpClient.Namespace}, &corev1.ConfigMap{}); err != nil {
+	configMap, err := cb.Build(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	oldConfigMap := new(corev1.ConfigMap)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: frpClient.Name, Namespace: frpClient.Namespace}, oldConfigMap); err != nil {
 		if apierrors.IsNotFound(err) {
-			return k8sClient.Create(ctx, configMap)
+			return config, configMap, k8sClient.Create(ctx, configMap)
 		}
-		return err
+		return nil, nil, err
 	} else {
+		// every write makes the sidecar reload frpc, which can drop connections
+		if reflect.DeepEqual(oldConfigMap.Data, configMap.Data) &&
+			oldConfigMap.Annotations[builder.ConfigHashAnnotation] == configMap.Annotations[builder.ConfigHashAnnotation] {
+			return config, configMap, nil
+		}
+		configMap.ResourceVersion = oldConfigMap.ResourceVersion
 		if err := k8sClient.Update(ctx, configMap); err != nil {
-			return err
+			return nil, nil, err
 		}
 	}
-	return nil
+	return config, configMap, nil
+}
+
+// tryCreateAdminSecret creates the admin credentials of the Client once, they are kept
+// for the lifetime of the Client and garbage collected with it.
+func tryCreateAdminSecret(ctx context.Context, k8sClient client.Client, scheme *runtime.Scheme, frpClient *frpcv1.Client) error {
+	name := builder.AdminSecretName(frpClient.Name)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: name, Namespace: frpClient.Namespace}, &corev1.Secret{}); !apierrors.IsNotFound(err) {
+		return err
+	}
+	secret, err := builder.NewAdminSecretBuilder().SetName(name).SetNamespace(frpClient.Namespace).Build()
+	if err != nil {
+		return err
+	}
+	if err := controllerutil.SetControllerReference(frpClient, secret, scheme); err != nil {
+		return err
+	}
+	return k8sClient.Create(ctx, secret)
 }
 
 func tryCreateRbac(ctx context.Context, k8sClient client.Client, namespace string, serviceAccountName string, roleName string, bindingName string) error {
@@ -51,3 +95,133 @@ func tryCreateRbac(ctx context.Context, k8sClient client.Client, namespace strin
 	}
 	return nil
 }
+
+// clientSecretNames returns the Secrets the config of the Client reads from.
+func clientSecretNames(frpClient *frpcv1.Client) []string {
+	var names []string
+	if valueFrom := frpClient.Spec.Common.Token.ValueFrom; valueFrom != nil && valueFrom.SecretKeyRef != nil {
+		names = append(names, valueFrom.SecretKeyRef.Name)
+	}
+	if auth := frpClient.Spec.Common.Auth; auth != nil && auth.OIDC != nil {
+		names = append(names, auth.OIDC.ClientSecret.Name)
+	}
+	if tls := frpClient.Spec.Common.TLS; tls != nil {
+		if tls.CertSecret != "" {
+			names = append(names, tls.CertSecret)
+		}
+		if tls.TrustedCASecret != "" {
+			names = append(names, tls.TrustedCASecret)
+		}
+	}
+	if outbound := frpClient.Spec.Common.OutboundProxy; outbound != nil && outbound.Credentials != nil {
+		names = append(names, outbound.Credentials.Password.Name)
+	}
+	if adminTLS := frpClient.Spec.Common.AdminTLS; adminTLS != nil {
+		names = append(names, adminTLS.CertSecret)
+	}
+	return names
+}
+
+// clientConfigMapNames returns the ConfigMaps the config of the Client reads from.
+func clientConfigMapNames(frpClient *frpcv1.Client) []string {
+	var names []string
+	if valueFrom := frpClient.Spec.Common.Token.ValueFrom; valueFrom != nil && valueFrom.ConfigMapKeyRef != nil {
+		names = append(names, valueFrom.ConfigMapKeyRef.Name)
+	}
+	return names
+}
+
+// proxySecretNames returns the Secrets the config of the Proxy reads from.
+func proxySecretNames(proxy *frpcv1.Proxy) []string {
+	var names []string
+	spec := proxy.Spec
+	switch {
+	case spec.STCPProxy != nil:
+		names = append(names, spec.STCPProxy.SK.Name)
+	case spec.XTCPProxy != nil:
+		names = append(names, spec.XTCPProxy.SK.Name)
+	case spec.SUDPProxy != nil:
+		names = append(names, spec.SUDPProxy.SK.Name)
+	}
+	if spec.Group != nil {
+		names = append(names, spec.Group.Key.Name)
+	}
+	if spec.Plugin != nil {
+		var credentials *frpcv1.Credentials
+		switch {
+		case spec.Plugin.Socks5 != nil:
+			credentials = spec.Plugin.Socks5.Credentials
+		case spec.Plugin.HTTPProxy != nil:
+			credentials = spec.Plugin.HTTPProxy.Credentials
+		case spec.Plugin.StaticFile != nil:
+			credentials = spec.Plugin.StaticFile.Credentials
+		}
+		if credentials != nil {
+			names = append(names, credentials.Password.Name)
+		}
+		if spec.Plugin.HTTPS2HTTP != nil {
+			names = append(names, spec.Plugin.HTTPS2HTTP.CertSecret)
+		}
+	}
+	return names
+}
+
+// visitorSecretNames returns the Secrets the config of the Visitor reads from.
+func visitorSecretNames(visitor *frpcv1.Visitor) []string {
+	var names []string
+	switch {
+	case visitor.Spec.STCPVisitor != nil:
+		names = append(names, visitor.Spec.STCPVisitor.SK.Name)
+	case visitor.Spec.XTCPVisitor != nil:
+		names = append(names, visitor.Spec.XTCPVisitor.SK.Name)
+	case visitor.Spec.SUDPVisitor != nil:
+		names = append(names, visitor.Spec.SUDPVisitor.SK.Name)
+	}
+	return names
+}
+
+// proxyPhases maps the proxy states of frpc to ProxyStatus phases.
+var proxyPhases = map[string]string{
+	admin.StatusNew:         "Pending",
+	admin.StatusWaitStart:   "WaitStart",
+	admin.StatusStartError:  "StartError",
+	admin.StatusRunning:     "Running",
+	admin.StatusCheckFailed: "CheckFailed",
+	admin.StatusClosed:      "Closed",
+}
+
+// newProxyStatus returns the status of a proxy from what frpc reports, a proxy frpc
+// does not know yet is Pending.
+func newProxyStatus(old frpcv1.ProxyStatus, reported *admin.ProxyStatus, now metav1.Time) frpcv1.ProxyStatus {
+	if reported == nil {
+		return unreportedProxyStatus(old, "Pending", "NotLoaded", now)
+	}
+	status := frpcv1.ProxyStatus{Phase: proxyPhases[reported.Status], LastError: reported.Err, RemoteAddr: reported.RemoteAddr}
+	if status.Phase == "" {
+		status.Phase, status.Reason = "Pending", "UnknownStatus"
+	}
+	return withTransitionTime(old, status, now)
+}
+
+// unreportedProxyStatus returns the status of a proxy whose state cannot be read from frpc.
+func unreportedProxyStatus(old frpcv1.ProxyStatus, phase string, reason string, now metav1.Time) frpcv1.ProxyStatus {
+	return withTransitionTime(old, frpcv1.ProxyStatus{Phase: phase, Reason: reason}, now)
+}
+
+// withTransitionTime keeps the transition time of old unless the phase changed.
+func withTransitionTime(old frpcv1.ProxyStatus, status frpcv1.ProxyStatus, now metav1.Time) frpcv1.ProxyStatus {
+	status.LastTransitionTime = old.LastTransitionTime
+	if status.Phase != old.Phase || status.LastTransitionTime == nil {
+		status.LastTransitionTime = &now
+	}
+	return status
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,152 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/YoogoC/frpc-operator/admin"
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	if err := frpcv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := appsv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := rbacv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func newTestClient() *frpcv1.Client {
+	frpClient := &frpcv1.Client{}
+	frpClient.Name, frpClient.Namespace = "client", "default"
+	frpClient.Spec.FrpVersion = "v0.52.0"
+	frpClient.Spec.Common.ServerAddr = "frps.example.com"
+	frpClient.Spec.Common.ServerPort = 7000
+	return frpClient
+}
+
+func newTestProxy(name string, remotePort int) *frpcv1.Proxy {
+	proxy := &frpcv1.Proxy{}
+	proxy.Name, proxy.Namespace = name, "default"
+	proxy.Spec.Client = "client"
+	proxy.Spec.LocalAddr, proxy.Spec.LocalPort = name, "80"
+	proxy.Spec.TCPProxy = &frpcv1.TCPProxy{RemotePort: fmt.Sprint(remotePort)}
+	return proxy
+}
+
+// shuffledClient rotates the proxies it lists by one more on every call.
+type shuffledClient struct {
+	client.Client
+	calls int
+}
+
+func (c *shuffledClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := c.Client.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	if proxyList, ok := list.(*frpcv1.ProxyList); ok && len(proxyList.Items) > 0 {
+		c.calls++
+		n := c.calls % len(proxyList.Items)
+		proxyList.Items = append(proxyList.Items[n:], proxyList.Items[:n]...)
+	}
+	return nil
+}
+
+func TestCreateOrUpdateConfigMapUnchanged(t *testing.T) {
+	ctx := context.Background()
+	frpClient := newTestClient()
+	names := []string{"a", "b", "c", "d", "e"}
+	k8sClient := newFakeClient(t, frpClient)
+	for i, name := range names {
+		if err := k8sClient.Create(ctx, newTestProxy(name, 6000+i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, configMap, err := createOrUpdateConfigMap(ctx, k8sClient, frpClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	created := &corev1.ConfigMap{}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(configMap), created); err != nil {
+		t.Fatal(err)
+	}
+
+	// every list returns the proxies in another order, which must not change the config
+	shuffled := &shuffledClient{Client: k8sClient}
+	for i := 0; i < 10; i++ {
+		if _, _, err := createOrUpdateConfigMap(ctx, shuffled, frpClient); err != nil {
+			t.Fatal(err)
+		}
+		updated := &corev1.ConfigMap{}
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(configMap), updated); err != nil {
+			t.Fatal(err)
+		}
+		if updated.ResourceVersion != created.ResourceVersion {
+			t.Fatalf("the ConfigMap was written again, resourceVersion %s, want %s", updated.ResourceVersion, created.ResourceVersion)
+		}
+	}
+
+	// a changed proxy is written
+	changed := &frpcv1.Proxy{}
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: "a", Namespace: "default"}, changed); err != nil {
+		t.Fatal(err)
+	}
+	changed.Spec.TCPProxy.RemotePort = "7000"
+	if err := k8sClient.Update(ctx, changed); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := createOrUpdateConfigMap(ctx, k8sClient, frpClient); err != nil {
+		t.Fatal(err)
+	}
+	updated := &corev1.ConfigMap{}
+	if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(configMap), updated); err != nil {
+		t.Fatal(err)
+	}
+	if updated.ResourceVersion == created.ResourceVersion {
+		t.Error("the ConfigMap was not updated for a changed proxy")
+	}
+}
+
+func TestNewProxyStatus(t *testing.T) {
+	then := metav1.NewTime(time.Now().Add(-time.Hour).Truncate(time.Second))
+	now := metav1.NewTime(then.Add(time.Hour))
+	running := &admin.ProxyStatus{Name: "web", Status: admin.StatusRunning, RemoteAddr: ":6000"}
+
+	kept := newProxyStatus(frpcv1.ProxyStatus{Phase: "Running", LastTransitionTime: &then}, running, now)
+	if kept.Phase != "Running" || kept.RemoteAddr != ":6000" || !kept.LastTransitionTime.Equal(&then) {
+		t.Errorf("the same phase changed the transition time: %+v", kept)
+	}
+
+	changed := newProxyStatus(frpcv1.ProxyStatus{Phase: "Pending", Reason: "NotLoaded", LastTransitionTime: &then}, running, now)
+	if changed.Phase != "Running" || changed.Reason != "" || !changed.LastTransitionTime.Equal(&now) {
+		t.Errorf("a new phase kept the transition time: %+v", changed)
+	}
+
+	pending := newProxyStatus(frpcv1.ProxyStatus{}, nil, now)
+	if pending.Phase != "Pending" || pending.Reason != "NotLoaded" || !pending.LastTransitionTime.Equal(&now) {
+		t.Errorf("unexpected status of a proxy frpc does not know: %+v", pending)
+	}
+
+	unknown := unreportedProxyStatus(frpcv1.ProxyStatus{Phase: "Running", RemoteAddr: ":6000", LastTransitionTime: &then}, "Unknown", "AdminAPIUnreachable", now)
+	if unknown.Phase != "Unknown" || unknown.Reason != "AdminAPIUnreachable" || unknown.RemoteAddr != "" || !unknown.LastTransitionTime.Equal(&now) {
+		t.Errorf("unexpected status of an unreachable frpc: %+v", unknown)
+	}
+}
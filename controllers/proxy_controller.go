@@ -102,7 +102,8 @@ func (r *ProxyReconciler) reloadConfigMap(ctx context.Context, proxy *frpcv1.Pro
 	if frpClient.DeletionTimestamp != nil {
 		return nil
 	}
-	if err := createOrUpdateConfigMap(ctx, r.Client, frpClient); err != nil {
+	if _, _, err := createOrUpdateConfigMap(ctx, r.Client, frpClient); err != nil && !isConfigError(err) {
+		// unsupported features and invalid values are reported on the Client
 		return err
 	}
 	return nil
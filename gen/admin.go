@@ -0,0 +1,57 @@
+package gen
+
+import (
+	"context"
+	"path"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	defaultAdminAddress = "0.0.0.0"
+	defaultAdminPort    = 7400
+	adminTLSMountPath   = "/tls/admin"
+)
+
+// Admin is the admin API of frpc, the config-reload sidecar calls it after the config changed.
+type Admin struct {
+	AdminAddress     string
+	AdminPort        int
+	AdminUsername    string `gen:"template"`
+	AdminPassword    string `gen:"template"`
+	AdminTLSCertFile string
+	AdminTLSKeyFile  string
+}
+
+func newAdmin(ctx context.Context, k8sClient client.Client, clientObj *frpcv1.Client) (Admin, []Volume, error) {
+	spec := clientObj.Spec.Common
+	admin := Admin{
+		AdminAddress:  spec.AdminAddr,
+		AdminPort:     spec.AdminPort,
+		AdminUsername: envPlaceholder(AdminUsernameEnv),
+		AdminPassword: envPlaceholder(AdminPasswordEnv),
+	}
+	if admin.AdminAddress == "" {
+		admin.AdminAddress = defaultAdminAddress
+	}
+	if admin.AdminPort == 0 {
+		admin.AdminPort = defaultAdminPort
+	}
+	if spec.AdminTLS == nil {
+		return admin, nil, nil
+	}
+	volume, err := secretVolume(ctx, k8sClient, clientObj.Namespace, spec.AdminTLS.CertSecret, "admin-tls", adminTLSMountPath, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+	if err != nil {
+		return Admin{}, nil, err
+	}
+	admin.AdminTLSCertFile = path.Join(adminTLSMountPath, corev1.TLSCertKey)
+	admin.AdminTLSKeyFile = path.Join(adminTLSMountPath, corev1.TLSPrivateKeyKey)
+	return admin, []Volume{volume}, nil
+}
+
+// AdminTLS reports whether the admin API is served over https.
+func (admin Admin) AdminTLS() bool {
+	return admin.AdminTLSCertFile != ""
+}
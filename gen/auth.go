@@ -0,0 +1,54 @@
+package gen
+
+import (
+	"context"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Auth is how frpc authenticates to frps, AuthenticationMethod is either token or oidc.
+// Secret values are placeholders filled in by frpc, see Env.
+type Auth struct {
+	AuthenticationMethod     string
+	Token                    string `gen:"secret"`
+	AuthenticateHeartbeats   bool
+	AuthenticateNewWorkConns bool
+
+	OIDCClientID                 string
+	OIDCClientSecret             string `gen:"secret"`
+	OIDCAudience                 string
+	OIDCTokenEndpointURL         string
+	OIDCAdditionalEndpointParams map[string]string
+}
+
+func newAuth(ctx context.Context, k8sClient client.Client, clientObj *frpcv1.Client, envs *secretEnvs) (Auth, error) {
+	spec := clientObj.Spec.Common.Auth
+	if spec == nil {
+		spec = &frpcv1.ClientAuth{}
+	}
+	auth := Auth{
+		AuthenticateHeartbeats:   spec.AuthenticateHeartbeats,
+		AuthenticateNewWorkConns: spec.AuthenticateNewWorkConns,
+	}
+	if spec.OIDC != nil {
+		clientSecret, err := envs.ref(ctx, k8sClient, clientObj.Namespace, spec.OIDC.ClientSecret)
+		if err != nil {
+			return Auth{}, err
+		}
+		auth.AuthenticationMethod = "oidc"
+		auth.OIDCClientID = spec.OIDC.ClientID
+		auth.OIDCClientSecret = clientSecret
+		auth.OIDCAudience = spec.OIDC.Audience
+		auth.OIDCTokenEndpointURL = spec.OIDC.TokenEndpointURL
+		auth.OIDCAdditionalEndpointParams = spec.OIDC.AdditionalEndpointParams
+		return auth, nil
+	}
+	token, err := tokenValue(ctx, k8sClient, clientObj, envs)
+	if err != nil {
+		return Auth{}, err
+	}
+	auth.AuthenticationMethod = "token"
+	auth.Token = token
+	return auth, nil
+}
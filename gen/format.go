@@ -0,0 +1,95 @@
+package gen
+
+import (
+	"encoding/json"
+
+	"sigs.k8s.io/yaml"
+)
+
+// Format is the file format of the frpc config.
+type Format string
+
+const (
+	FormatINI  Format = "ini"
+	FormatTOML Format = "toml"
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// Encoder renders the config in one format.
+type Encoder interface {
+	Encode(config *FrpcConfig) (string, error)
+}
+
+// EncoderFunc adapts a function to an Encoder.
+type EncoderFunc func(config *FrpcConfig) (string, error)
+
+func (f EncoderFunc) Encode(config *FrpcConfig) (string, error) {
+	return f(config)
+}
+
+var encoders = map[Format]Encoder{
+	FormatINI:  EncoderFunc(encodeINI),
+	FormatTOML: EncoderFunc(encodeTOML),
+	FormatYAML: EncoderFunc(encodeYAML),
+	FormatJSON: EncoderFunc(encodeJSON),
+}
+
+// ResolveFormat returns format, or infers it from the frpc version when it is empty:
+// frpc since v0.52 deprecates ini in favour of toml.
+func ResolveFormat(format string, version Version) (Format, error) {
+	if format != "" {
+		if _, ok := encoders[Format(format)]; !ok {
+			return "", &ValidationError{Field: "configFormat", Value: format, Reason: "unknown config format"}
+		}
+		return Format(format), nil
+	}
+	if version.AtLeast(v1Since) {
+		return FormatTOML, nil
+	}
+	return FormatINI, nil
+}
+
+// FileName is the name of the config file in the ConfigMap and in the frpc container.
+func (format Format) FileName() string {
+	if format == "" {
+		format = FormatINI
+	}
+	return "config." + string(format)
+}
+
+func encodeJSON(config *FrpcConfig) (string, error) {
+	v1, err := config.v1()
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(v1, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+func encodeYAML(config *FrpcConfig) (string, error) {
+	v1, err := config.v1()
+	if err != nil {
+		return "", err
+	}
+	data, err := yaml.Marshal(v1)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+func encodeTOML(config *FrpcConfig) (string, error) {
+	v1, err := config.v1()
+	if err != nil {
+		return "", err
+	}
+	data, err := marshalTOML(v1)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
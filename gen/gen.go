@@ -1,9 +1,11 @@
 package gen
 
 import (
-	"bytes"
-	_ "embed"
-	"text/template"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
 
 	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -13,69 +15,453 @@ import (
 // type TCPProxy config.TCPProxyConf
 
 type FrpcConfig struct {
-	Common     ClientCommon
-	TCPProxies []TCPProxy
+	Version       Version
+	Format        Format
+	Common        ClientCommon
+	TCPProxies    []TCPProxy
+	UDPProxies    []UDPProxy
+	HTTPProxies   []HTTPProxy
+	HTTPSProxies  []HTTPSProxy
+	STCPProxies   []STCPProxy
+	XTCPProxies   []XTCPProxy
+	SUDPProxies   []SUDPProxy
+	TCPMuxProxies []TCPMuxProxy
+	STCPVisitors  []STCPVisitor
+	XTCPVisitors  []XTCPVisitor
+	SUDPVisitors  []SUDPVisitor
+	Volumes       []Volume `gen:"-"`
+	Envs          []Env    `gen:"-"`
 }
 
 type ClientCommon struct {
 	ServerAddress string
 	ServerPort    int
-	Token         string
-	AdminAddress  string
-	AdminPort     int
-	AdminUsername string
-	AdminPassword string
+	Auth
+	TLS
+	ServerTransport
+	HTTPProxy string `gen:"secret"`
+	DNSServer string
+	Log
+	Admin
+
+	NatHoleSTUNServer string
 }
 
 type TCPProxy struct {
-	Name       string
-	LocalAddr  string
-	LocalPort  string
+	Name string
+	LocalService
+	Transport
+	LoadBalancer
+	RemotePort string
+}
+
+type UDPProxy struct {
+	Name string
+	LocalService
+	Transport
 	RemotePort string
 }
 
-//go:embed frpc.ini.tmpl
-var frpcIniTmpl string
+type HTTPProxy struct {
+	Name string
+	LocalService
+	Transport
+	LoadBalancer
+	CustomDomains     []string
+	SubDomain         string
+	Locations         []string
+	HostHeaderRewrite string
+	Headers           map[string]string
+	HTTPUser          string
+	HTTPPwd           string
+}
+
+type HTTPSProxy struct {
+	Name string
+	LocalService
+	Transport
+	CustomDomains []string
+	SubDomain     string
+}
+
+type STCPProxy struct {
+	Name string
+	LocalService
+	Transport
+	SK string `gen:"secret"`
+}
+
+type STCPVisitor struct {
+	Name string
+	Transport
+	ServerName string
+	SK         string `gen:"secret"`
+	BindAddr   string
+	BindPort   int
+}
+
+type XTCPProxy struct {
+	Name string
+	LocalService
+	Transport
+	SK string `gen:"secret"`
+}
+
+type XTCPVisitor struct {
+	Name string
+	Transport
+	ServerName        string
+	SK                string `gen:"secret"`
+	BindAddr          string
+	BindPort          int
+	FallbackTo        string
+	FallbackTimeoutMs int
+}
+
+type SUDPProxy struct {
+	Name string
+	LocalService
+	Transport
+	SK string `gen:"secret"`
+}
 
-func NewConfig(k8sClient client.Client, clientObj *frpcv1.Client, proxies []frpcv1.Proxy) (*FrpcConfig, error) {
+type SUDPVisitor struct {
+	Name string
+	Transport
+	ServerName string
+	SK         string `gen:"secret"`
+	BindAddr   string
+	BindPort   int
+}
+
+type TCPMuxProxy struct {
+	Name string
+	LocalService
+	Transport
+	LoadBalancer
+	Multiplexer     string
+	CustomDomains   []string
+	SubDomain       string
+	RouteByHTTPUser string
+	HTTPUser        string
+	HTTPPwd         string
+}
+
+// The admin credentials are not written into the config, frpc reads them from these
+// environment variables when it loads the config, like the values of Secrets, see Env.
+const (
+	AdminUsernameEnv = "FRPC_ADMIN_USER"
+	AdminPasswordEnv = "FRPC_ADMIN_PWD"
+)
+
+func NewConfig(ctx context.Context, k8sClient client.Client, clientObj *frpcv1.Client, proxies []frpcv1.Proxy, visitors []frpcv1.Visitor) (*FrpcConfig, error) {
 	var tcpProxies []TCPProxy
+	var udpProxies []UDPProxy
+	var httpProxies []HTTPProxy
+	var httpsProxies []HTTPSProxy
+	var stcpProxies []STCPProxy
+	var xtcpProxies []XTCPProxy
+	var sudpProxies []SUDPProxy
+	var tcpMuxProxies []TCPMuxProxy
+	var volumes []Volume
+	envs := &secretEnvs{}
+	// List returns objects in no particular order, the config must only change with their content
+	proxies = append([]frpcv1.Proxy(nil), proxies...)
+	sort.SliceStable(proxies, func(i, j int) bool { return proxies[i].Name < proxies[j].Name })
+	visitors = append([]frpcv1.Visitor(nil), visitors...)
+	sort.SliceStable(visitors, func(i, j int) bool { return visitors[i].Name < visitors[j].Name })
 	for _, proxy := range proxies {
-		tcpProxies = append(tcpProxies, TCPProxy{
-			Name:       proxy.Name,
-			LocalAddr:  proxy.Spec.LocalAddr,
-			LocalPort:  proxy.Spec.LocalPort,
-			RemotePort: proxy.Spec.TCPProxy.RemotePort,
-		})
+		spec := proxy.Spec
+		if n := countSet(spec.TCPProxy != nil, spec.UDPProxy != nil, spec.HTTPProxy != nil, spec.HTTPSProxy != nil,
+			spec.STCPProxy != nil, spec.XTCPProxy != nil, spec.SUDPProxy != nil, spec.TCPMuxProxy != nil); n != 1 {
+			return nil, specError("proxy", proxy.Name, "must set exactly one proxy type, %d are set", n)
+		}
+		local, localVolumes, err := newLocalService(ctx, k8sClient, &proxy, envs)
+		if err != nil {
+			return nil, err
+		}
+		if local.Plugin != nil && (proxy.Spec.UDPProxy != nil || proxy.Spec.SUDPProxy != nil) {
+			return nil, specError("proxy", proxy.Name, "plugins are not supported on udp proxies")
+		}
+		volumes = append(volumes, localVolumes...)
+		lb, err := newLoadBalancer(ctx, k8sClient, &proxy, envs)
+		if err != nil {
+			return nil, err
+		}
+		transport := newProxyTransport(&proxy)
+		switch {
+		case proxy.Spec.TCPProxy != nil:
+			tcpProxies = append(tcpProxies, TCPProxy{
+				Name:         proxy.Name,
+				LocalService: local,
+				Transport:    transport,
+				LoadBalancer: lb,
+				RemotePort:   proxy.Spec.TCPProxy.RemotePort,
+			})
+		case proxy.Spec.UDPProxy != nil:
+			udpProxies = append(udpProxies, UDPProxy{
+				Name:         proxy.Name,
+				LocalService: local,
+				Transport:    transport,
+				RemotePort:   proxy.Spec.UDPProxy.RemotePort,
+			})
+		case proxy.Spec.HTTPProxy != nil:
+			if len(proxy.Spec.HTTPProxy.CustomDomains) == 0 && proxy.Spec.HTTPProxy.SubDomain == "" {
+				return nil, specError("proxy", proxy.Name, "http proxies require custom_domains or subdomain")
+			}
+			httpProxies = append(httpProxies, HTTPProxy{
+				Name:              proxy.Name,
+				LocalService:      local,
+				Transport:         transport,
+				LoadBalancer:      lb,
+				CustomDomains:     proxy.Spec.HTTPProxy.CustomDomains,
+				SubDomain:         proxy.Spec.HTTPProxy.SubDomain,
+				Locations:         proxy.Spec.HTTPProxy.Locations,
+				HostHeaderRewrite: proxy.Spec.HTTPProxy.HostHeaderRewrite,
+				Headers:           proxy.Spec.HTTPProxy.Headers,
+				HTTPUser:          proxy.Spec.HTTPProxy.HTTPUser,
+				HTTPPwd:           proxy.Spec.HTTPProxy.HTTPPwd,
+			})
+		case proxy.Spec.HTTPSProxy != nil:
+			if len(proxy.Spec.HTTPSProxy.CustomDomains) == 0 && proxy.Spec.HTTPSProxy.SubDomain == "" {
+				return nil, specError("proxy", proxy.Name, "https proxies require custom_domains or subdomain")
+			}
+			httpsProxies = append(httpsProxies, HTTPSProxy{
+				Name:          proxy.Name,
+				LocalService:  local,
+				Transport:     transport,
+				CustomDomains: proxy.Spec.HTTPSProxy.CustomDomains,
+				SubDomain:     proxy.Spec.HTTPSProxy.SubDomain,
+			})
+		case proxy.Spec.STCPProxy != nil:
+			sk, err := envs.ref(ctx, k8sClient, proxy.Namespace, proxy.Spec.STCPProxy.SK)
+			if err != nil {
+				return nil, err
+			}
+			stcpProxies = append(stcpProxies, STCPProxy{
+				Name:         proxy.Name,
+				LocalService: local,
+				Transport:    transport,
+				SK:           sk,
+			})
+		case proxy.Spec.XTCPProxy != nil:
+			sk, err := envs.ref(ctx, k8sClient, proxy.Namespace, proxy.Spec.XTCPProxy.SK)
+			if err != nil {
+				return nil, err
+			}
+			xtcpProxies = append(xtcpProxies, XTCPProxy{
+				Name:         proxy.Name,
+				LocalService: local,
+				Transport:    transport,
+				SK:           sk,
+			})
+		case proxy.Spec.SUDPProxy != nil:
+			sk, err := envs.ref(ctx, k8sClient, proxy.Namespace, proxy.Spec.SUDPProxy.SK)
+			if err != nil {
+				return nil, err
+			}
+			sudpProxies = append(sudpProxies, SUDPProxy{
+				Name:         proxy.Name,
+				LocalService: local,
+				Transport:    transport,
+				SK:           sk,
+			})
+		case proxy.Spec.TCPMuxProxy != nil:
+			if len(proxy.Spec.TCPMuxProxy.CustomDomains) == 0 && proxy.Spec.TCPMuxProxy.SubDomain == "" {
+				return nil, specError("proxy", proxy.Name, "tcpmux proxies require custom_domains or subdomain")
+			}
+			multiplexer := proxy.Spec.TCPMuxProxy.Multiplexer
+			if multiplexer == "" {
+				multiplexer = "httpconnect"
+			}
+			tcpMuxProxies = append(tcpMuxProxies, TCPMuxProxy{
+				Name:            proxy.Name,
+				LocalService:    local,
+				Transport:       transport,
+				LoadBalancer:    lb,
+				Multiplexer:     multiplexer,
+				CustomDomains:   proxy.Spec.TCPMuxProxy.CustomDomains,
+				SubDomain:       proxy.Spec.TCPMuxProxy.SubDomain,
+				RouteByHTTPUser: proxy.Spec.TCPMuxProxy.RouteByHTTPUser,
+				HTTPUser:        proxy.Spec.TCPMuxProxy.HTTPUser,
+				HTTPPwd:         proxy.Spec.TCPMuxProxy.HTTPPwd,
+			})
+		}
+	}
+	var stcpVisitors []STCPVisitor
+	var xtcpVisitors []XTCPVisitor
+	var sudpVisitors []SUDPVisitor
+	for _, visitor := range visitors {
+		spec := visitor.Spec
+		if n := countSet(spec.STCPVisitor != nil, spec.XTCPVisitor != nil, spec.SUDPVisitor != nil); n != 1 {
+			return nil, specError("visitor", visitor.Name, "must set exactly one visitor type, %d are set", n)
+		}
+		switch {
+		case visitor.Spec.STCPVisitor != nil:
+			sk, err := envs.ref(ctx, k8sClient, visitor.Namespace, visitor.Spec.STCPVisitor.SK)
+			if err != nil {
+				return nil, err
+			}
+			stcpVisitors = append(stcpVisitors, STCPVisitor{
+				Name:       visitor.Name + "_visitor",
+				Transport:  newVisitorTransport(&visitor),
+				ServerName: visitor.Spec.STCPVisitor.ServerName,
+				SK:         sk,
+				BindAddr:   visitor.Spec.BindAddr,
+				BindPort:   visitor.Spec.BindPort,
+			})
+		case visitor.Spec.XTCPVisitor != nil:
+			sk, err := envs.ref(ctx, k8sClient, visitor.Namespace, visitor.Spec.XTCPVisitor.SK)
+			if err != nil {
+				return nil, err
+			}
+			var fallbackTo string
+			if visitor.Spec.XTCPVisitor.FallbackTo != "" {
+				if !hasSTCPVisitor(visitors, visitor.Spec.XTCPVisitor.FallbackTo) {
+					return nil, specError("visitor", visitor.Name, "falls back to %s, which is not a stcp visitor of the same client", visitor.Spec.XTCPVisitor.FallbackTo)
+				}
+				fallbackTo = visitor.Spec.XTCPVisitor.FallbackTo + "_visitor"
+			}
+			xtcpVisitors = append(xtcpVisitors, XTCPVisitor{
+				Name:              visitor.Name + "_visitor",
+				Transport:         newVisitorTransport(&visitor),
+				ServerName:        visitor.Spec.XTCPVisitor.ServerName,
+				SK:                sk,
+				BindAddr:          visitor.Spec.BindAddr,
+				BindPort:          visitor.Spec.BindPort,
+				FallbackTo:        fallbackTo,
+				FallbackTimeoutMs: visitor.Spec.XTCPVisitor.FallbackTimeoutMs,
+			})
+		case visitor.Spec.SUDPVisitor != nil:
+			sk, err := envs.ref(ctx, k8sClient, visitor.Namespace, visitor.Spec.SUDPVisitor.SK)
+			if err != nil {
+				return nil, err
+			}
+			sudpVisitors = append(sudpVisitors, SUDPVisitor{
+				Name:       visitor.Name + "_visitor",
+				Transport:  newVisitorTransport(&visitor),
+				ServerName: visitor.Spec.SUDPVisitor.ServerName,
+				SK:         sk,
+				BindAddr:   visitor.Spec.BindAddr,
+				BindPort:   visitor.Spec.BindPort,
+			})
+		}
+	}
+	auth, err := newAuth(ctx, k8sClient, clientObj, envs)
+	if err != nil {
+		return nil, err
+	}
+	tls, tlsVolumes, err := newTLS(ctx, k8sClient, clientObj)
+	if err != nil {
+		return nil, err
+	}
+	volumes = append(volumes, tlsVolumes...)
+	admin, adminVolumes, err := newAdmin(ctx, k8sClient, clientObj)
+	if err != nil {
+		return nil, err
+	}
+	volumes = append(volumes, adminVolumes...)
+	serverTransport, err := newServerTransport(clientObj)
+	if err != nil {
+		return nil, err
+	}
+	httpProxy, err := outboundProxyURL(ctx, k8sClient, clientObj, envs)
+	if err != nil {
+		return nil, err
+	}
+	version, err := ClientVersion(clientObj)
+	if err != nil {
+		return nil, err
+	}
+	format, err := ResolveFormat(clientObj.Spec.ConfigFormat, version)
+	if err != nil {
+		return nil, err
 	}
 	frpcConfig := &FrpcConfig{
+		Version: version,
+		Format:  format,
 		Common: ClientCommon{
 			ServerAddress: clientObj.Spec.Common.ServerAddr,
 			ServerPort:    clientObj.Spec.Common.ServerPort,
-			Token:         clientObj.Spec.Common.Token.Value, // TODO
-			AdminAddress:  "0.0.0.0",                         // TODO
-			AdminPort:     7400,                              // TODO
-			AdminUsername: "frpc-admin",                      // TODO
-			AdminPassword: "frpc-password",                   // TODO
+			Auth:          auth,
+			TLS:           tls,
+
+			ServerTransport: serverTransport,
+			HTTPProxy:       httpProxy,
+			DNSServer:       clientObj.Spec.Common.DNSServer,
+			Log:             newLog(clientObj),
+			Admin:           admin,
+
+			NatHoleSTUNServer: clientObj.Spec.Common.NatHoleSTUNServer,
 		},
-		TCPProxies: tcpProxies,
+		TCPProxies:    tcpProxies,
+		UDPProxies:    udpProxies,
+		HTTPProxies:   httpProxies,
+		HTTPSProxies:  httpsProxies,
+		STCPProxies:   stcpProxies,
+		XTCPProxies:   xtcpProxies,
+		SUDPProxies:   sudpProxies,
+		TCPMuxProxies: tcpMuxProxies,
+		STCPVisitors:  stcpVisitors,
+		XTCPVisitors:  xtcpVisitors,
+		SUDPVisitors:  sudpVisitors,
+		Volumes:       volumes,
+		Envs:          envs.envs,
+	}
+	if err := frpcConfig.checkVersion(); err != nil {
+		return nil, err
 	}
 	return frpcConfig, nil
 }
 
+// countSet returns how many fields of a one-of are set, the API types document which
+// fields belong together.
+func countSet(set ...bool) int {
+	n := 0
+	for _, isSet := range set {
+		if isSet {
+			n++
+		}
+	}
+	return n
+}
+
+func hasSTCPVisitor(visitors []frpcv1.Visitor, name string) bool {
+	for _, visitor := range visitors {
+		if visitor.Name == name && visitor.Spec.STCPVisitor != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// CommonChecksum changes whenever the [common] section changes, frpc only applies it on start.
+// The section is hashed as json, which writes the values pointers point to.
+func (config *FrpcConfig) CommonChecksum() string {
+	// cannot fail, the section only holds strings, numbers, bools and string maps
+	data, _ := json.Marshal(config.Common)
+	checksum := sha256.Sum256(data)
+	return hex.EncodeToString(checksum[:])
+}
+
+// Gen validates the config and renders it in its Format, ini unless set.
 func (config *FrpcConfig) Gen() (string, error) {
-	tmpl, err := template.New("frpc").Parse(frpcIniTmpl)
-	if err != nil {
-		return "", err
+	format := config.Format
+	if format == "" {
+		format = FormatINI
+	}
+	encoder, ok := encoders[format]
+	if !ok {
+		return "", &ValidationError{Field: "configFormat", Value: string(format), Reason: "unknown config format"}
 	}
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, config); err != nil {
+	if err := config.validate(); err != nil {
 		return "", err
 	}
-	return string(buf.Bytes()), nil
+	return encoder.Encode(config)
 }
 
-func Gen(k8sClient client.Client, clientObj *frpcv1.Client, proxies []frpcv1.Proxy) (string, error) {
-	config, err := NewConfig(k8sClient, clientObj, proxies)
+func Gen(ctx context.Context, k8sClient client.Client, clientObj *frpcv1.Client, proxies []frpcv1.Proxy, visitors []frpcv1.Visitor) (string, error) {
+	config, err := NewConfig(ctx, k8sClient, clientObj, proxies, visitors)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,375 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fuzzInput is interpolated into the config, every field comes from a CR.
+type fuzzInput struct {
+	name, localAddr, localPort, remotePort, token, headerKey, headerValue, domain string
+}
+
+func addSeeds(f *testing.F) {
+	seeds := []fuzzInput{
+		{"web", "127.0.0.1", "80", "6000", "secret", "X-From", "frp", "example.com"},
+		{"web", "127.0.0.1\n[common]\nserver_addr = evil", "80", "6000", "secret", "X-From", "frp", "example.com"},
+		{"web", "127.0.0.1", "80", "6000\r\n[extra]\ntype = tcp", "secret", "X-From", "frp", "example.com"},
+		{"common", "127.0.0.1", "80", "6000", "secret", "X-From", "frp", "example.com"},
+		{"web]\n[extra", "127.0.0.1", "80", "6000", "secret", "X-From", "frp", "example.com"},
+		{"web", "127.0.0.1", "80", "6000", "secret\n[extra]", "X-From", "frp", "example.com"},
+		{"web", "127.0.0.1", "80", "6000", "secret", "X-From = a\n[extra]\nb", "frp", "example.com"},
+		{"web", "127.0.0.1", "80", "6000", "secret", "X-From", `{{ "\n[extra]" }}`, "example.com"},
+		{"web", "127.0.0.1", "80", "6000", "{{ .Envs.FRPC_ADMIN_PWD }}", "X-From", "frp", "example.com"},
+		{"web", "127.0.0.1", "80", "6000", "secret", "X-From", "frp", "a.com,b.com\n[[proxies]]"},
+		{"web", `"127.0.0.1"`, "80", "6000", " secret ", "X-From", "frp", "example.com"},
+		{"web", "127.0.0.1", "80", "6000", "secret", "X-From", `frp\`, "example.com"},
+	}
+	for _, seed := range seeds {
+		f.Add(seed.name, seed.localAddr, seed.localPort, seed.remotePort, seed.token, seed.headerKey, seed.headerValue, seed.domain)
+	}
+}
+
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	if err := frpcv1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func newClientObj(version string, format string) *frpcv1.Client {
+	clientObj := &frpcv1.Client{}
+	clientObj.Name, clientObj.Namespace = "client", "default"
+	clientObj.Spec.FrpVersion = version
+	clientObj.Spec.ConfigFormat = format
+	clientObj.Spec.Common.ServerAddr = "frps.example.com"
+	clientObj.Spec.Common.ServerPort = 7000
+	return clientObj
+}
+
+func genFuzz(t *testing.T, version string, format string, in fuzzInput) (string, error) {
+	k8sClient := newFakeClient(t)
+	clientObj := newClientObj(version, format)
+	clientObj.Spec.Common.Token.Value = in.token
+
+	tcp := frpcv1.Proxy{}
+	tcp.Name, tcp.Namespace = in.name, "default"
+	tcp.Spec.Client = "client"
+	tcp.Spec.LocalAddr, tcp.Spec.LocalPort = in.localAddr, in.localPort
+	tcp.Spec.TCPProxy = &frpcv1.TCPProxy{RemotePort: in.remotePort}
+
+	http := frpcv1.Proxy{}
+	http.Name, http.Namespace = in.name+"-http", "default"
+	http.Spec.Client = "client"
+	http.Spec.LocalAddr, http.Spec.LocalPort = in.localAddr, in.localPort
+	http.Spec.HTTPProxy = &frpcv1.HTTPProxy{
+		CustomDomains: []string{in.domain},
+		Headers:       map[string]string{in.headerKey: in.headerValue},
+	}
+	return Gen(context.Background(), k8sClient, clientObj, []frpcv1.Proxy{tcp, http}, nil)
+}
+
+// checkTemplates makes sure the only template actions frpc runs are the admin credentials.
+func checkTemplates(t *testing.T, config string) {
+	if n := strings.Count(config, "{{"); n != 2 {
+		t.Fatalf("config has %d template actions, want 2:\n%s", n, config)
+	}
+}
+
+type iniParsedSection struct {
+	name string
+	keys []string
+}
+
+// parseINI returns the sections and keys go-ini, used by frpc, reads from config:
+// a line starting with [ starts a section, # and ; start comments, keys end at the
+// first = or : and a value ending in a backslash continues on the next line.
+func parseINI(config string) []iniParsedSection {
+	var sections []iniParsedSection
+	lines := strings.Split(config, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "", strings.HasPrefix(line, "#"), strings.HasPrefix(line, ";"):
+		case strings.HasPrefix(line, "["):
+			name, _, _ := strings.Cut(line[1:], "]")
+			sections = append(sections, iniParsedSection{name: strings.TrimSpace(name)})
+		default:
+			end := strings.IndexAny(line, "=:")
+			if end < 0 {
+				end = len(line)
+			}
+			if len(sections) == 0 {
+				sections = append(sections, iniParsedSection{name: "DEFAULT"})
+			}
+			last := &sections[len(sections)-1]
+			last.keys = append(last.keys, strings.TrimSpace(line[:end]))
+			for strings.HasSuffix(line, `\`) && i+1 < len(lines) {
+				i++
+				line = strings.TrimSpace(lines[i])
+			}
+		}
+	}
+	return sections
+}
+
+func FuzzGenINI(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, name, localAddr, localPort, remotePort, token, headerKey, headerValue, domain string) {
+		in := fuzzInput{name, localAddr, localPort, remotePort, token, headerKey, headerValue, domain}
+		config, err := genFuzz(t, "v0.44.0", "ini", in)
+		if err != nil {
+			return
+		}
+		common := []string{"server_addr", "server_port", "authentication_method"}
+		if token != "" {
+			common = append(common, "token")
+		}
+		common = append(common, "authenticate_heartbeats", "authenticate_new_work_conns", "admin_addr", "admin_port", "admin_user", "admin_pwd")
+		want := []iniParsedSection{
+			{"common", common},
+			{name, []string{"type", "local_ip", "local_port", "remote_port", "use_encryption", "use_compression"}},
+			{name + "-http", []string{"type", "local_ip", "local_port", "custom_domains", "header_" + headerKey, "use_encryption", "use_compression"}},
+		}
+		if got := parseINI(config); !reflect.DeepEqual(got, want) {
+			t.Fatalf("sections %q, want %q:\n%s", got, want, config)
+		}
+		checkTemplates(t, config)
+	})
+}
+
+func FuzzGenTOML(f *testing.F) {
+	addSeeds(f)
+	f.Fuzz(func(t *testing.T, name, localAddr, localPort, remotePort, token, headerKey, headerValue, domain string) {
+		in := fuzzInput{name, localAddr, localPort, remotePort, token, headerKey, headerValue, domain}
+		config, err := genFuzz(t, "v0.52.0", "toml", in)
+		if err != nil {
+			return
+		}
+		// strings are quoted and escaped, so every table header is one of ours
+		allowed := map[string]bool{
+			"[auth]":                       true,
+			"[webServer]":                  true,
+			"[[proxies]]":                  true,
+			"[proxies.transport]":          true,
+			"[proxies.requestHeaders.set]": true,
+		}
+		proxies := 0
+		for _, line := range strings.Split(config, "\n") {
+			line = strings.TrimSpace(line)
+			if !strings.HasPrefix(line, "[") {
+				continue
+			}
+			if !allowed[line] {
+				t.Fatalf("unexpected table %s:\n%s", line, config)
+			}
+			if line == "[[proxies]]" {
+				proxies++
+			}
+		}
+		if proxies != 2 {
+			t.Fatalf("config has %d proxies, want 2:\n%s", proxies, config)
+		}
+		checkTemplates(t, config)
+	})
+}
+
+func TestGenValidationError(t *testing.T) {
+	valid := fuzzInput{"web", "127.0.0.1", "80", "6000", "secret", "X-From", "frp", "example.com"}
+	if _, err := genFuzz(t, "v0.44.0", "ini", valid); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in    fuzzInput
+		field string
+	}{
+		{fuzzInput{"web", "127.0.0.1\n[common]", "80", "6000", "secret", "X-From", "frp", "example.com"}, "TCPProxies[web].LocalAddr"},
+		{fuzzInput{"web", "127.0.0.1", "80", "6000", "{{ .Envs.FRPC_ADMIN_PWD }}", "X-From", "frp", "example.com"}, "Common.Token"},
+		{fuzzInput{"common", "127.0.0.1", "80", "6000", "secret", "X-From", "frp", "example.com"}, "common"},
+		{fuzzInput{"web", "127.0.0.1", "80", "6000", "secret", "X-From", "frp", "a.com,b.com"}, "web-http.custom_domains"},
+		{fuzzInput{"web", "127.0.0.1", "80", "6000", "secret", "X-From", `frp\`, "example.com"}, "web-http.header_X-From"},
+	}
+	for _, test := range tests {
+		_, err := genFuzz(t, "v0.44.0", "ini", test.in)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("%+v: got %v, want a ValidationError", test.in, err)
+		}
+		if validationErr.Field != test.field {
+			t.Errorf("%+v: field %s, want %s", test.in, validationErr.Field, test.field)
+		}
+	}
+}
+
+func TestGenExactlyOne(t *testing.T) {
+	newProxy := func() frpcv1.Proxy {
+		proxy := frpcv1.Proxy{}
+		proxy.Name, proxy.Namespace = "web", "default"
+		proxy.Spec.Client = "client"
+		proxy.Spec.LocalAddr, proxy.Spec.LocalPort = "127.0.0.1", "80"
+		return proxy
+	}
+	twoTypes := newProxy()
+	twoTypes.Spec.TCPProxy = &frpcv1.TCPProxy{RemotePort: "6000"}
+	twoTypes.Spec.UDPProxy = &frpcv1.UDPProxy{RemotePort: "6000"}
+	noType := newProxy()
+	twoPlugins := newProxy()
+	twoPlugins.Spec.TCPProxy = &frpcv1.TCPProxy{RemotePort: "6000"}
+	twoPlugins.Spec.LocalAddr, twoPlugins.Spec.LocalPort = "", ""
+	twoPlugins.Spec.Plugin = &frpcv1.ProxyPlugin{Socks5: &frpcv1.Socks5Plugin{}, HTTPProxy: &frpcv1.HTTPProxyPlugin{}}
+	twoVisitors := frpcv1.Visitor{}
+	twoVisitors.Name, twoVisitors.Namespace = "db", "default"
+	twoVisitors.Spec.Client = "client"
+	twoVisitors.Spec.STCPVisitor = &frpcv1.STCPVisitor{ServerName: "db"}
+	twoVisitors.Spec.SUDPVisitor = &frpcv1.SUDPVisitor{ServerName: "db"}
+	twoSources := newClientObj("v0.44.0", "ini")
+	twoSources.Spec.Common.Token.ValueFrom = &frpcv1.TokenValueSource{
+		SecretKeyRef:    &corev1.SecretKeySelector{Key: "token"},
+		ConfigMapKeyRef: &corev1.ConfigMapKeySelector{Key: "token"},
+	}
+
+	tests := []struct {
+		name      string
+		clientObj *frpcv1.Client
+		proxies   []frpcv1.Proxy
+		visitors  []frpcv1.Visitor
+		err       string
+	}{
+		{"two proxy types", newClientObj("v0.44.0", "ini"), []frpcv1.Proxy{twoTypes}, nil, "exactly one proxy type, 2 are set"},
+		{"no proxy type", newClientObj("v0.44.0", "ini"), []frpcv1.Proxy{noType}, nil, "exactly one proxy type, 0 are set"},
+		{"two plugins", newClientObj("v0.44.0", "ini"), []frpcv1.Proxy{twoPlugins}, nil, "exactly one plugin type, 2 are set"},
+		{"two visitor types", newClientObj("v0.44.0", "ini"), nil, []frpcv1.Visitor{twoVisitors}, "exactly one visitor type, 2 are set"},
+		{"two token sources", twoSources, nil, nil, "exactly one value source, 2 are set"},
+	}
+	for _, test := range tests {
+		_, err := Gen(context.Background(), newFakeClient(t), test.clientObj, test.proxies, test.visitors)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("%s: got %v, want a ValidationError containing %q", test.name, err, test.err)
+		}
+	}
+}
+
+func TestPluginVolume(t *testing.T) {
+	proxy := &frpcv1.Proxy{}
+	proxy.Name = "static.files." + strings.Repeat("a", 60)
+	volumes, err := pluginVolume(proxy, frpcv1.PluginVolume{ConfigMap: &corev1.ConfigMapVolumeSource{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if errs := validation.IsDNS1123Label(volumes[0].Name); len(errs) > 0 {
+		t.Errorf("volume name %s: %v", volumes[0].Name, errs)
+	}
+	_, err = pluginVolume(proxy, frpcv1.PluginVolume{
+		ConfigMap:             &corev1.ConfigMapVolumeSource{},
+		PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "files"},
+	})
+	if err == nil {
+		t.Error("expected an error for two volume sources")
+	}
+}
+
+func TestGenSecretEnvs(t *testing.T) {
+	newSecret := func(name string, value string) *corev1.Secret {
+		secret := &corev1.Secret{Data: map[string][]byte{"key": []byte(value)}}
+		secret.Name, secret.Namespace = name, "default"
+		return secret
+	}
+	newProxy := func(name string) frpcv1.Proxy {
+		proxy := frpcv1.Proxy{}
+		proxy.Name, proxy.Namespace = name, "default"
+		proxy.Spec.Client = "client"
+		proxy.Spec.LocalAddr, proxy.Spec.LocalPort = "127.0.0.1", "22"
+		proxy.Spec.STCPProxy = &frpcv1.STCPProxy{SK: corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: "key",
+		}}
+		return proxy
+	}
+	for _, format := range []string{"ini", "toml"} {
+		clientObj := newClientObj("v0.52.0", format)
+		clientObj.Spec.Common.Token.ValueFrom = &frpcv1.TokenValueSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{Name: "token"}, Key: "key",
+		}}
+		k8sClient := newFakeClient(t, newSecret("token", "token-value"), newSecret("ssh", "ssh-sk"), newSecret("evil", "sk\n[common]"))
+
+		config, err := NewConfig(context.Background(), k8sClient, clientObj, []frpcv1.Proxy{newProxy("ssh")}, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := config.Gen()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(data, "token-value") || strings.Contains(data, "ssh-sk") {
+			t.Errorf("%s: Secret values are written into the config:\n%s", format, data)
+		}
+		if len(config.Envs) != 2 {
+			t.Fatalf("%s: got %d envs, want 2", format, len(config.Envs))
+		}
+		for _, env := range config.Envs {
+			if !strings.Contains(data, env.Placeholder()) {
+				t.Errorf("%s: placeholder of %s is missing:\n%s", format, env.Name, data)
+			}
+		}
+
+		_, err = Gen(context.Background(), k8sClient, clientObj, []frpcv1.Proxy{newProxy("evil")}, nil)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) {
+			t.Fatalf("%s: got %v, want a ValidationError", format, err)
+		}
+		if strings.Contains(err.Error(), "[common]") {
+			t.Errorf("%s: the error contains the Secret value: %v", format, err)
+		}
+	}
+}
+
+func TestCommonChecksum(t *testing.T) {
+	render := func(logLevel string) string {
+		tcpMux := true
+		clientObj := newClientObj("v0.44.0", "ini")
+		clientObj.Spec.Common.Transport = &frpcv1.ClientTransport{TCPMux: &tcpMux}
+		clientObj.Spec.Logging = &frpcv1.ClientLogging{LogLevel: logLevel}
+		config, err := NewConfig(context.Background(), newFakeClient(t), clientObj, nil, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return config.CommonChecksum()
+	}
+	if render("info") != render("info") {
+		t.Error("two renders of the same Client have different checksums")
+	}
+	if render("info") == render("debug") {
+		t.Error("the checksum does not change with the log level")
+	}
+}
+
+func TestGenAdminTLSINI(t *testing.T) {
+	secret := &corev1.Secret{Data: map[string][]byte{corev1.TLSCertKey: []byte("cert"), corev1.TLSPrivateKeyKey: []byte("key")}}
+	secret.Name, secret.Namespace = "admin-tls", "default"
+	clientObj := newClientObj("v0.52.0", "ini")
+	clientObj.Spec.Common.AdminTLS = &frpcv1.AdminTLS{CertSecret: "admin-tls"}
+
+	_, err := Gen(context.Background(), newFakeClient(t, secret), clientObj, nil, nil)
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || validationErr.Field != "configFormat" {
+		t.Fatalf("got %v, want a ValidationError for configFormat", err)
+	}
+	clientObj.Spec.ConfigFormat = "toml"
+	if _, err := Gen(context.Background(), newFakeClient(t, secret), clientObj, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,156 @@
+package gen
+
+import (
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func secretKey(name string, key string) corev1.SecretKeySelector {
+	return corev1.SecretKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: name}, Key: key}
+}
+
+// goldenObjects returns a Client using most of the config, its proxies and visitors and the
+// Secrets they read from.
+func goldenObjects(version string, format string) (*frpcv1.Client, []frpcv1.Proxy, []frpcv1.Visitor, []client.Object) {
+	newSecret := func(name string, data map[string]string) client.Object {
+		secret := &corev1.Secret{Data: map[string][]byte{}}
+		secret.Name, secret.Namespace = name, "default"
+		for key, value := range data {
+			secret.Data[key] = []byte(value)
+		}
+		return secret
+	}
+	secrets := []client.Object{
+		newSecret("frps", map[string]string{"token": "token-value"}),
+		newSecret("tls", map[string]string{corev1.TLSCertKey: "cert", corev1.TLSPrivateKeyKey: "key"}),
+		newSecret("ca", map[string]string{"ca.crt": "ca"}),
+		newSecret("sk", map[string]string{"sk": "sk-value"}),
+		newSecret("group", map[string]string{"key": "group-key"}),
+		newSecret("socks", map[string]string{"password": "socks-password"}),
+	}
+
+	tcpMux := true
+	clientObj := newClientObj(version, format)
+	clientObj.Spec.Common.Token.ValueFrom = &frpcv1.TokenValueSource{SecretKeyRef: &corev1.SecretKeySelector{
+		LocalObjectReference: corev1.LocalObjectReference{Name: "frps"}, Key: "token",
+	}}
+	clientObj.Spec.Common.TLS = &frpcv1.ClientTLS{CertSecret: "tls", TrustedCASecret: "ca", ServerName: "frps.example.com"}
+	clientObj.Spec.Common.Transport = &frpcv1.ClientTransport{Protocol: "tcp", TCPMux: &tcpMux, PoolCount: 2}
+	clientObj.Spec.Common.NatHoleSTUNServer = "stun.example.com:3478"
+	clientObj.Spec.Logging = &frpcv1.ClientLogging{LogLevel: "info"}
+
+	newProxy := func(name string) frpcv1.Proxy {
+		proxy := frpcv1.Proxy{}
+		proxy.Name, proxy.Namespace = name, "default"
+		proxy.Spec.Client = "client"
+		proxy.Spec.LocalAddr, proxy.Spec.LocalPort = name+".default.svc", "8080"
+		return proxy
+	}
+	tcp := newProxy("tcp")
+	tcp.Spec.TCPProxy = &frpcv1.TCPProxy{RemotePort: "6000"}
+	tcp.Spec.Group = &frpcv1.ProxyGroup{Name: "web", Key: secretKey("group", "key")}
+	tcp.Spec.HealthCheck = &frpcv1.ProxyHealthCheck{Type: "tcp", TimeoutSeconds: 3}
+	tcp.Spec.BandwidthLimit = "1MB"
+	udp := newProxy("udp")
+	udp.Spec.UDPProxy = &frpcv1.UDPProxy{RemotePort: "6001"}
+	http := newProxy("http")
+	http.Spec.HTTPProxy = &frpcv1.HTTPProxy{
+		CustomDomains:     []string{"a.example.com", "b.example.com"},
+		Locations:         []string{"/api"},
+		HostHeaderRewrite: "backend",
+		Headers:           map[string]string{"X-From": "frp"},
+	}
+	http.Spec.ProxyProtocolVersion = "v2"
+	stcp := newProxy("stcp")
+	stcp.Spec.STCPProxy = &frpcv1.STCPProxy{SK: secretKey("sk", "sk")}
+	socks := newProxy("socks")
+	socks.Spec.LocalAddr, socks.Spec.LocalPort = "", ""
+	socks.Spec.TCPProxy = &frpcv1.TCPProxy{RemotePort: "6002"}
+	socks.Spec.Plugin = &frpcv1.ProxyPlugin{Socks5: &frpcv1.Socks5Plugin{
+		Credentials: &frpcv1.Credentials{User: "user", Password: secretKey("socks", "password")},
+	}}
+	static := newProxy("static")
+	static.Spec.LocalAddr, static.Spec.LocalPort = "", ""
+	static.Spec.HTTPProxy = &frpcv1.HTTPProxy{SubDomain: "files"}
+	static.Spec.Plugin = &frpcv1.ProxyPlugin{StaticFile: &frpcv1.StaticFilePlugin{
+		Volume:      frpcv1.PluginVolume{ConfigMap: &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: "files"}}},
+		SubPath:     "public",
+		StripPrefix: "static",
+	}}
+	proxies := []frpcv1.Proxy{udp, tcp, http, stcp, socks, static}
+
+	newVisitor := func(name string, port int) frpcv1.Visitor {
+		visitor := frpcv1.Visitor{}
+		visitor.Name, visitor.Namespace = name, "default"
+		visitor.Spec.Client = "client"
+		visitor.Spec.BindAddr, visitor.Spec.BindPort = "127.0.0.1", port
+		return visitor
+	}
+	stcpVisitor := newVisitor("db", 9000)
+	stcpVisitor.Spec.STCPVisitor = &frpcv1.STCPVisitor{ServerName: "db", SK: secretKey("sk", "sk")}
+	xtcpVisitor := newVisitor("db-p2p", 9001)
+	xtcpVisitor.Spec.XTCPVisitor = &frpcv1.XTCPVisitor{ServerName: "db-p2p", SK: secretKey("sk", "sk"), FallbackTo: "db", FallbackTimeoutMs: 1000}
+	visitors := []frpcv1.Visitor{stcpVisitor, xtcpVisitor}
+	return clientObj, proxies, visitors, secrets
+}
+
+func TestGenGolden(t *testing.T) {
+	tests := []struct {
+		version string
+		format  string
+	}{
+		{"v0.51.0", "ini"},
+		{"v0.52.0", "toml"},
+		{"v0.52.0", "yaml"},
+		{"v0.52.0", "json"},
+	}
+	for _, test := range tests {
+		t.Run(test.format, func(t *testing.T) {
+			clientObj, proxies, visitors, secrets := goldenObjects(test.version, test.format)
+			config, err := Gen(context.Background(), newFakeClient(t, secrets...), clientObj, proxies, visitors)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", "golden."+test.format)
+			if *update {
+				if err := os.WriteFile(golden, []byte(config), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if config != string(want) {
+				t.Errorf("config differs from %s, run go test ./gen -run TestGenGolden -update after checking it:\n%s", golden, config)
+			}
+		})
+	}
+}
+
+func TestTOMLString(t *testing.T) {
+	tests := map[string]string{
+		`plain`:           `"plain"`,
+		`a"b\c`:           `"a\"b\\c"`,
+		"tab\tnl\n":       `"tab\tnl\n"`,
+		"bell\x07del\x7f": `"bell\u0007del\u007F"`,
+		"日本":              `"日本"`,
+	}
+	for s, want := range tests {
+		if got := tomlString(s); got != want {
+			t.Errorf("tomlString(%q) = %s, want %s", s, got, want)
+		}
+	}
+	if got := tomlKey("a.b"); got != `"a.b"` {
+		t.Errorf("tomlKey(a.b) = %s, want a quoted key", got)
+	}
+}
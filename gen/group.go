@@ -0,0 +1,59 @@
+package gen
+
+import (
+	"context"
+	"sort"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LoadBalancer is rendered verbatim for every member, so that proxies of one group agree
+// on name and key regardless of their Client or namespace.
+type LoadBalancer struct {
+	Group    string
+	GroupKey string `gen:"secret"`
+}
+
+func newLoadBalancer(ctx context.Context, k8sClient client.Client, proxy *frpcv1.Proxy, envs *secretEnvs) (LoadBalancer, error) {
+	if proxy.Spec.Group == nil {
+		return LoadBalancer{}, nil
+	}
+	if proxy.Spec.TCPProxy == nil && proxy.Spec.HTTPProxy == nil && proxy.Spec.TCPMuxProxy == nil {
+		return LoadBalancer{}, specError("proxy", proxy.Name, "only tcp, http and tcpmux proxies can join a group")
+	}
+	groupKey, err := envs.ref(ctx, k8sClient, proxy.Namespace, proxy.Spec.Group.Key)
+	if err != nil {
+		return LoadBalancer{}, err
+	}
+	return LoadBalancer{Group: proxy.Spec.Group.Name, GroupKey: groupKey}, nil
+}
+
+// Groups returns the sorted names of all load balancing groups in the config.
+func (config *FrpcConfig) Groups() []string {
+	seen := map[string]bool{}
+	var groups []string
+	add := func(lb LoadBalancer) {
+		if lb.Group != "" && !seen[lb.Group] {
+			seen[lb.Group] = true
+			groups = append(groups, lb.Group)
+		}
+	}
+	for _, proxy := range config.TCPProxies {
+		add(proxy.LoadBalancer)
+	}
+	for _, proxy := range config.HTTPProxies {
+		add(proxy.LoadBalancer)
+	}
+	for _, proxy := range config.TCPMuxProxies {
+		add(proxy.LoadBalancer)
+	}
+	sort.Strings(groups)
+	return groups
+}
+
+// ProxyCount returns the number of proxies in the config.
+func (config *FrpcConfig) ProxyCount() int {
+	return len(config.TCPProxies) + len(config.UDPProxies) + len(config.HTTPProxies) + len(config.HTTPSProxies) +
+		len(config.STCPProxies) + len(config.XTCPProxies) + len(config.SUDPProxies) + len(config.TCPMuxProxies)
+}
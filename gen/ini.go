@@ -0,0 +1,414 @@
+package gen
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// iniFile is the ini config read by frpc before v0.52, a list of sections with ordered keys.
+type iniFile struct {
+	sections []*iniSection
+	// expand fills in the Secret values, go-ini parses the config after frpc did that.
+	expand func(string) string
+}
+
+type iniSection struct {
+	name   string
+	keys   [][2]string
+	err    error
+	expand func(string) string
+}
+
+var (
+	// iniName matches section names and map keys which go-ini, used by frpc, reads back unchanged.
+	iniName = regexp.MustCompile(`^[A-Za-z0-9!#$%&'*+.^_|~-]+$`)
+	// iniCommon is the section frpc reads its own settings from.
+	iniCommon = "common"
+)
+
+func (file *iniFile) section(name string) *iniSection {
+	section := &iniSection{name: name, expand: file.expand}
+	file.sections = append(file.sections, section)
+	return section
+}
+
+// set adds a key, values are written verbatim so they must not be changed by go-ini:
+// surrounding blanks are trimmed, leading quotes are taken as quoting and a trailing
+// backslash joins the next line to the value.
+func (section *iniSection) set(key string, value interface{}) {
+	if section.err != nil {
+		return
+	}
+	s := fmt.Sprint(value)
+	if b, ok := value.(*bool); ok {
+		s = strconv.FormatBool(*b)
+	}
+	field := section.name + "." + key
+	if !iniName.MatchString(key) {
+		section.err = &ValidationError{Field: field, Value: key, Reason: "not a valid ini key"}
+		return
+	}
+	expanded := s
+	if section.expand != nil {
+		expanded = section.expand(s)
+	}
+	if expanded != strings.TrimSpace(expanded) {
+		section.err = &ValidationError{Field: field, Value: s, Reason: "leading or trailing blanks are dropped by the ini config"}
+		return
+	}
+	if strings.HasPrefix(expanded, `"`) || strings.HasPrefix(expanded, "`") {
+		section.err = &ValidationError{Field: field, Value: s, Reason: "leading quotes are taken as quoting by the ini config"}
+		return
+	}
+	if strings.HasSuffix(expanded, `\`) {
+		section.err = &ValidationError{Field: field, Value: s, Reason: "a trailing backslash continues the value on the next line in the ini config"}
+		return
+	}
+	section.keys = append(section.keys, [2]string{key, s})
+}
+
+// setList adds a comma separated list, its elements must not contain commas.
+func (section *iniSection) setList(key string, values []string) {
+	for _, value := range values {
+		if strings.Contains(value, ",") {
+			section.err = &ValidationError{Field: section.name + "." + key, Value: value, Reason: "list elements cannot contain a comma in the ini config"}
+			return
+		}
+	}
+	section.set(key, strings.Join(values, ","))
+}
+
+func (section *iniSection) setMap(prefix string, values map[string]string) {
+	for _, key := range sortedKeys(values) {
+		section.set(prefix+key, values[key])
+	}
+}
+
+func (file *iniFile) encode() (string, error) {
+	seen := map[string]bool{}
+	var buf strings.Builder
+	for i, section := range file.sections {
+		if section.err != nil {
+			return "", section.err
+		}
+		if !iniName.MatchString(section.name) {
+			return "", &ValidationError{Field: section.name, Value: section.name, Reason: "not a valid ini section name"}
+		}
+		if seen[section.name] || (i > 0 && section.name == iniCommon) {
+			return "", &ValidationError{Field: section.name, Value: section.name, Reason: "the name of another section in the ini config"}
+		}
+		seen[section.name] = true
+		if i > 0 {
+			buf.WriteString("\n")
+		}
+		fmt.Fprintf(&buf, "[%s]\n", section.name)
+		for _, kv := range section.keys {
+			fmt.Fprintf(&buf, "%s = %s\n", kv[0], kv[1])
+		}
+	}
+	return buf.String(), nil
+}
+
+// encodeINI renders the ini config read by frpc before v0.52.
+func encodeINI(config *FrpcConfig) (string, error) {
+	// the ini config has no settings for serving the admin api over tls,
+	// refuse instead of silently serving plain http.
+	if config.Common.AdminTLS() {
+		return "", &ValidationError{Field: "configFormat", Value: string(FormatINI), Reason: "admin_tls needs the toml, yaml or json config, frpc has no tls settings for the admin api in the ini config"}
+	}
+	file := iniFile{expand: config.expand}
+	config.Common.ini(file.section(iniCommon))
+	for _, p := range config.TCPProxies {
+		s := file.section(p.Name)
+		s.set("type", "tcp")
+		p.LocalService.ini(s)
+		p.LoadBalancer.ini(s)
+		s.set("remote_port", p.RemotePort)
+		p.Transport.ini(s)
+	}
+	for _, p := range config.UDPProxies {
+		s := file.section(p.Name)
+		s.set("type", "udp")
+		p.LocalService.ini(s)
+		s.set("remote_port", p.RemotePort)
+		p.Transport.ini(s)
+	}
+	for _, p := range config.HTTPProxies {
+		s := file.section(p.Name)
+		s.set("type", "http")
+		p.LocalService.ini(s)
+		p.LoadBalancer.ini(s)
+		if len(p.CustomDomains) > 0 {
+			s.setList("custom_domains", p.CustomDomains)
+		}
+		if p.SubDomain != "" {
+			s.set("subdomain", p.SubDomain)
+		}
+		if len(p.Locations) > 0 {
+			s.setList("locations", p.Locations)
+		}
+		if p.HostHeaderRewrite != "" {
+			s.set("host_header_rewrite", p.HostHeaderRewrite)
+		}
+		s.setMap("header_", p.Headers)
+		if p.HTTPUser != "" {
+			s.set("http_user", p.HTTPUser)
+			s.set("http_pwd", p.HTTPPwd)
+		}
+		p.Transport.ini(s)
+	}
+	for _, p := range config.HTTPSProxies {
+		s := file.section(p.Name)
+		s.set("type", "https")
+		p.LocalService.ini(s)
+		if len(p.CustomDomains) > 0 {
+			s.setList("custom_domains", p.CustomDomains)
+		}
+		if p.SubDomain != "" {
+			s.set("subdomain", p.SubDomain)
+		}
+		p.Transport.ini(s)
+	}
+	for _, p := range config.STCPProxies {
+		s := file.section(p.Name)
+		s.set("type", "stcp")
+		s.set("sk", p.SK)
+		p.LocalService.ini(s)
+		p.Transport.ini(s)
+	}
+	for _, p := range config.XTCPProxies {
+		s := file.section(p.Name)
+		s.set("type", "xtcp")
+		s.set("sk", p.SK)
+		p.LocalService.ini(s)
+		p.Transport.ini(s)
+	}
+	for _, p := range config.SUDPProxies {
+		s := file.section(p.Name)
+		s.set("type", "sudp")
+		s.set("sk", p.SK)
+		p.LocalService.ini(s)
+		p.Transport.ini(s)
+	}
+	for _, p := range config.TCPMuxProxies {
+		s := file.section(p.Name)
+		s.set("type", "tcpmux")
+		s.set("multiplexer", p.Multiplexer)
+		p.LocalService.ini(s)
+		p.LoadBalancer.ini(s)
+		if len(p.CustomDomains) > 0 {
+			s.setList("custom_domains", p.CustomDomains)
+		}
+		if p.SubDomain != "" {
+			s.set("subdomain", p.SubDomain)
+		}
+		if p.RouteByHTTPUser != "" {
+			s.set("route_by_http_user", p.RouteByHTTPUser)
+		}
+		if p.HTTPUser != "" {
+			s.set("http_user", p.HTTPUser)
+			s.set("http_pwd", p.HTTPPwd)
+		}
+		p.Transport.ini(s)
+	}
+	visitor := func(name string, visitorType string, serverName string, sk string, bindAddr string, bindPort int) *iniSection {
+		s := file.section(name)
+		s.set("type", visitorType)
+		s.set("role", "visitor")
+		s.set("server_name", serverName)
+		s.set("sk", sk)
+		s.set("bind_addr", bindAddr)
+		s.set("bind_port", bindPort)
+		return s
+	}
+	for _, v := range config.STCPVisitors {
+		v.Transport.ini(visitor(v.Name, "stcp", v.ServerName, v.SK, v.BindAddr, v.BindPort))
+	}
+	for _, v := range config.XTCPVisitors {
+		s := visitor(v.Name, "xtcp", v.ServerName, v.SK, v.BindAddr, v.BindPort)
+		if v.FallbackTo != "" {
+			s.set("fallback_to", v.FallbackTo)
+		}
+		if v.FallbackTimeoutMs != 0 {
+			s.set("fallback_timeout_ms", v.FallbackTimeoutMs)
+		}
+		v.Transport.ini(s)
+	}
+	for _, v := range config.SUDPVisitors {
+		v.Transport.ini(visitor(v.Name, "sudp", v.ServerName, v.SK, v.BindAddr, v.BindPort))
+	}
+	return file.encode()
+}
+
+func (common ClientCommon) ini(s *iniSection) {
+	s.set("server_addr", common.ServerAddress)
+	s.set("server_port", common.ServerPort)
+	st := common.ServerTransport
+	if st.Protocol != "" {
+		s.set("protocol", st.Protocol)
+	}
+	if st.TCPMux != nil {
+		s.set("tcp_mux", st.TCPMux)
+	}
+	if st.PoolCount != 0 {
+		s.set("pool_count", st.PoolCount)
+	}
+	if st.HeartbeatInterval != 0 {
+		s.set("heartbeat_interval", st.HeartbeatInterval)
+	}
+	if st.HeartbeatTimeout != 0 {
+		s.set("heartbeat_timeout", st.HeartbeatTimeout)
+	}
+	if st.DialServerTimeout != 0 {
+		s.set("dial_server_timeout", st.DialServerTimeout)
+	}
+	if st.ConnectServerLocalIP != "" {
+		s.set("connect_server_local_ip", st.ConnectServerLocalIP)
+	}
+	if st.QUICKeepalivePeriod != 0 {
+		s.set("quic_keepalive_period", st.QUICKeepalivePeriod)
+	}
+	if st.QUICMaxIdleTimeout != 0 {
+		s.set("quic_max_idle_timeout", st.QUICMaxIdleTimeout)
+	}
+	if st.QUICMaxIncomingStreams != 0 {
+		s.set("quic_max_incoming_streams", st.QUICMaxIncomingStreams)
+	}
+	if common.HTTPProxy != "" {
+		s.set("http_proxy", common.HTTPProxy)
+	}
+	if common.DNSServer != "" {
+		s.set("dns_server", common.DNSServer)
+	}
+	if common.LogLevel != "" {
+		s.set("log_level", common.LogLevel)
+	}
+	if common.LogFile != "" {
+		s.set("log_file", common.LogFile)
+	}
+	if common.LogMaxDays != 0 {
+		s.set("log_max_days", common.LogMaxDays)
+	}
+	if common.DisableLogColor {
+		s.set("disable_log_color", true)
+	}
+
+	s.set("authentication_method", common.AuthenticationMethod)
+	if common.Token != "" {
+		s.set("token", common.Token)
+	}
+	if common.AuthenticationMethod == "oidc" {
+		s.set("oidc_client_id", common.OIDCClientID)
+		s.set("oidc_client_secret", common.OIDCClientSecret)
+		if common.OIDCAudience != "" {
+			s.set("oidc_audience", common.OIDCAudience)
+		}
+		s.set("oidc_token_endpoint_url", common.OIDCTokenEndpointURL)
+		s.setMap("oidc_additional_", common.OIDCAdditionalEndpointParams)
+	}
+	s.set("authenticate_heartbeats", common.AuthenticateHeartbeats)
+	s.set("authenticate_new_work_conns", common.AuthenticateNewWorkConns)
+	if common.TLSEnable {
+		s.set("tls_enable", true)
+		if common.TLSCertFile != "" {
+			s.set("tls_cert_file", common.TLSCertFile)
+			s.set("tls_key_file", common.TLSKeyFile)
+		}
+		if common.TLSTrustedCAFile != "" {
+			s.set("tls_trusted_ca_file", common.TLSTrustedCAFile)
+		}
+		if common.TLSServerName != "" {
+			s.set("tls_server_name", common.TLSServerName)
+		}
+	}
+
+	s.set("admin_addr", common.AdminAddress)
+	s.set("admin_port", common.AdminPort)
+	s.set("admin_user", common.AdminUsername)
+	s.set("admin_pwd", common.AdminPassword)
+	if common.NatHoleSTUNServer != "" {
+		s.set("nat_hole_stun_server", common.NatHoleSTUNServer)
+	}
+}
+
+func (transport Transport) ini(s *iniSection) {
+	s.set("use_encryption", transport.UseEncryption)
+	s.set("use_compression", transport.UseCompression)
+	if transport.BandwidthLimit != "" {
+		s.set("bandwidth_limit", transport.BandwidthLimit)
+	}
+	if transport.ProxyProtocolVersion != "" {
+		s.set("proxy_protocol_version", transport.ProxyProtocolVersion)
+	}
+}
+
+func (lb LoadBalancer) ini(s *iniSection) {
+	if lb.Group != "" {
+		s.set("group", lb.Group)
+		s.set("group_key", lb.GroupKey)
+	}
+}
+
+func (local LocalService) ini(s *iniSection) {
+	if plugin := local.Plugin; plugin != nil {
+		s.set("plugin", plugin.Type)
+		if plugin.User != "" {
+			s.set("plugin_user", plugin.User)
+			s.set("plugin_passwd", plugin.Passwd)
+		}
+		if plugin.HTTPUser != "" {
+			s.set("plugin_http_user", plugin.HTTPUser)
+			s.set("plugin_http_passwd", plugin.HTTPPasswd)
+		}
+		if plugin.LocalPath != "" {
+			s.set("plugin_local_path", plugin.LocalPath)
+		}
+		if plugin.StripPrefix != "" {
+			s.set("plugin_strip_prefix", plugin.StripPrefix)
+		}
+		if plugin.UnixPath != "" {
+			s.set("plugin_unix_path", plugin.UnixPath)
+		}
+		if plugin.LocalAddr != "" {
+			s.set("plugin_local_addr", plugin.LocalAddr)
+		}
+		if plugin.CrtPath != "" {
+			s.set("plugin_crt_path", plugin.CrtPath)
+			s.set("plugin_key_path", plugin.KeyPath)
+		}
+		if plugin.HostHeaderRewrite != "" {
+			s.set("plugin_host_header_rewrite", plugin.HostHeaderRewrite)
+		}
+		s.setMap("plugin_header_", plugin.Headers)
+	} else {
+		s.set("local_ip", local.LocalAddr)
+		s.set("local_port", local.LocalPort)
+	}
+	if hc := local.HealthCheck; hc != nil {
+		s.set("health_check_type", hc.Type)
+		if hc.URL != "" {
+			s.set("health_check_url", hc.URL)
+		}
+		if hc.TimeoutS != 0 {
+			s.set("health_check_timeout_s", hc.TimeoutS)
+		}
+		if hc.MaxFailed != 0 {
+			s.set("health_check_max_failed", hc.MaxFailed)
+		}
+		if hc.IntervalS != 0 {
+			s.set("health_check_interval_s", hc.IntervalS)
+		}
+	}
+}
+
+func sortedKeys(values map[string]string) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
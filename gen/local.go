@@ -0,0 +1,68 @@
+package gen
+
+import (
+	"context"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// LocalService is where frpc sends the traffic of a proxy, either a local address or a plugin.
+type LocalService struct {
+	LocalAddr   string
+	LocalPort   string
+	Plugin      *Plugin
+	HealthCheck *HealthCheck
+}
+
+type HealthCheck struct {
+	Type      string
+	URL       string
+	TimeoutS  int
+	MaxFailed int
+	IntervalS int
+}
+
+func newLocalService(ctx context.Context, k8sClient client.Client, proxy *frpcv1.Proxy, envs *secretEnvs) (LocalService, []Volume, error) {
+	if proxy.Spec.Plugin == nil {
+		if proxy.Spec.LocalAddr == "" || proxy.Spec.LocalPort == "" {
+			return LocalService{}, nil, specError("proxy", proxy.Name, "requires local_addr and local_port or a plugin")
+		}
+		healthCheck, err := newHealthCheck(proxy)
+		if err != nil {
+			return LocalService{}, nil, err
+		}
+		return LocalService{LocalAddr: proxy.Spec.LocalAddr, LocalPort: proxy.Spec.LocalPort, HealthCheck: healthCheck}, nil, nil
+	}
+	if proxy.Spec.HealthCheck != nil {
+		return LocalService{}, nil, specError("proxy", proxy.Name, "health checks need local_addr and local_port, they cannot be used with a plugin")
+	}
+	plugin, volumes, err := newPlugin(ctx, k8sClient, proxy, envs)
+	if err != nil {
+		return LocalService{}, nil, err
+	}
+	return LocalService{Plugin: plugin}, volumes, nil
+}
+
+func newHealthCheck(proxy *frpcv1.Proxy) (*HealthCheck, error) {
+	spec := proxy.Spec.HealthCheck
+	if spec == nil {
+		return nil, nil
+	}
+	switch spec.Type {
+	case "tcp":
+	case "http":
+		if spec.URL == "" {
+			return nil, specError("proxy", proxy.Name, "http health checks require url")
+		}
+	default:
+		return nil, specError("proxy", proxy.Name, "unknown health check type %q", spec.Type)
+	}
+	return &HealthCheck{
+		Type:      spec.Type,
+		URL:       spec.URL,
+		TimeoutS:  spec.TimeoutSeconds,
+		MaxFailed: spec.MaxFailed,
+		IntervalS: spec.IntervalSeconds,
+	}, nil
+}
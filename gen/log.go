@@ -0,0 +1,25 @@
+package gen
+
+import (
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+)
+
+type Log struct {
+	LogLevel        string
+	LogFile         string
+	LogMaxDays      int
+	DisableLogColor bool
+}
+
+func newLog(clientObj *frpcv1.Client) Log {
+	spec := clientObj.Spec.Logging
+	if spec == nil {
+		return Log{}
+	}
+	return Log{
+		LogLevel:        spec.LogLevel,
+		LogFile:         spec.LogFile,
+		LogMaxDays:      spec.LogMaxDays,
+		DisableLogColor: spec.DisableLogColor,
+	}
+}
@@ -0,0 +1,37 @@
+package gen
+
+import (
+	"context"
+	"net/url"
+	"strings"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// outboundProxyURL returns the http_proxy of frpc, the password is a placeholder frpc fills in.
+func outboundProxyURL(ctx context.Context, k8sClient client.Client, clientObj *frpcv1.Client, envs *secretEnvs) (string, error) {
+	spec := clientObj.Spec.Common.OutboundProxy
+	if spec == nil {
+		return "", nil
+	}
+	proxyURL, err := url.Parse(spec.URL)
+	if err != nil {
+		return "", specError("client", clientObj.Name, "invalid http_proxy url: %v", err)
+	}
+	if spec.Credentials == nil {
+		return proxyURL.String(), nil
+	}
+	placeholder, err := envs.ref(ctx, k8sClient, clientObj.Namespace, spec.Credentials.Password)
+	if err != nil {
+		return "", err
+	}
+	// the placeholder must not be escaped, so neither can the password frpc puts in its place
+	user := url.User(spec.Credentials.User).String()
+	if password := envs.value(placeholder); url.UserPassword(spec.Credentials.User, password).String() != user+":"+password {
+		return "", &ValidationError{Field: "Common.HTTPProxy", Value: placeholder, Reason: "the password contains characters which need escaping in a url"}
+	}
+	proxyURL.User = nil
+	scheme := proxyURL.Scheme + "://"
+	return scheme + user + ":" + placeholder + "@" + strings.TrimPrefix(proxyURL.String(), scheme), nil
+}
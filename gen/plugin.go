@@ -0,0 +1,137 @@
+package gen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"path"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const pluginMountRoot = "/plugins"
+
+type Plugin struct {
+	Type              string
+	User              string
+	Passwd            string `gen:"secret"`
+	HTTPUser          string
+	HTTPPasswd        string `gen:"secret"`
+	LocalPath         string
+	StripPrefix       string
+	UnixPath          string
+	LocalAddr         string
+	CrtPath           string
+	KeyPath           string
+	HostHeaderRewrite string
+	Headers           map[string]string
+}
+
+// Volume must be mounted into the frpc container at MountPath for the files referenced by the config to exist.
+// Checksum is set for volumes whose content is only read when frpc starts.
+type Volume struct {
+	Name      string
+	MountPath string
+	Source    corev1.VolumeSource
+	Checksum  string
+}
+
+func newPlugin(ctx context.Context, k8sClient client.Client, proxy *frpcv1.Proxy, envs *secretEnvs) (*Plugin, []Volume, error) {
+	spec := proxy.Spec.Plugin
+	mountPath := path.Join(pluginMountRoot, proxy.Name)
+	if n := countSet(spec.Socks5 != nil, spec.HTTPProxy != nil, spec.StaticFile != nil,
+		spec.UnixDomainSocket != nil, spec.HTTPS2HTTP != nil, spec.HTTP2HTTPS != nil); n != 1 {
+		return nil, nil, specError("proxy", proxy.Name, "must set exactly one plugin type, %d are set", n)
+	}
+	switch {
+	case spec.Socks5 != nil:
+		plugin := &Plugin{Type: "socks5"}
+		if spec.Socks5.Credentials != nil {
+			passwd, err := envs.ref(ctx, k8sClient, proxy.Namespace, spec.Socks5.Credentials.Password)
+			if err != nil {
+				return nil, nil, err
+			}
+			plugin.User = spec.Socks5.Credentials.User
+			plugin.Passwd = passwd
+		}
+		return plugin, nil, nil
+	case spec.HTTPProxy != nil:
+		plugin := &Plugin{Type: "http_proxy"}
+		if spec.HTTPProxy.Credentials != nil {
+			passwd, err := envs.ref(ctx, k8sClient, proxy.Namespace, spec.HTTPProxy.Credentials.Password)
+			if err != nil {
+				return nil, nil, err
+			}
+			plugin.HTTPUser = spec.HTTPProxy.Credentials.User
+			plugin.HTTPPasswd = passwd
+		}
+		return plugin, nil, nil
+	case spec.StaticFile != nil:
+		plugin := &Plugin{
+			Type:        "static_file",
+			LocalPath:   path.Join(mountPath, spec.StaticFile.SubPath),
+			StripPrefix: spec.StaticFile.StripPrefix,
+		}
+		if spec.StaticFile.Credentials != nil {
+			passwd, err := envs.ref(ctx, k8sClient, proxy.Namespace, spec.StaticFile.Credentials.Password)
+			if err != nil {
+				return nil, nil, err
+			}
+			plugin.HTTPUser = spec.StaticFile.Credentials.User
+			plugin.HTTPPasswd = passwd
+		}
+		volumes, err := pluginVolume(proxy, spec.StaticFile.Volume)
+		return plugin, volumes, err
+	case spec.UnixDomainSocket != nil:
+		plugin := &Plugin{
+			Type:     "unix_domain_socket",
+			UnixPath: path.Join(mountPath, spec.UnixDomainSocket.Path),
+		}
+		volumes, err := pluginVolume(proxy, spec.UnixDomainSocket.Volume)
+		return plugin, volumes, err
+	case spec.HTTPS2HTTP != nil:
+		plugin := &Plugin{
+			Type:              "https2http",
+			LocalAddr:         spec.HTTPS2HTTP.LocalAddr,
+			CrtPath:           path.Join(mountPath, corev1.TLSCertKey),
+			KeyPath:           path.Join(mountPath, corev1.TLSPrivateKeyKey),
+			HostHeaderRewrite: spec.HTTPS2HTTP.HostHeaderRewrite,
+			Headers:           spec.HTTPS2HTTP.Headers,
+		}
+		volume, err := secretVolume(ctx, k8sClient, proxy.Namespace, spec.HTTPS2HTTP.CertSecret, pluginVolumeName(proxy), mountPath, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		return plugin, []Volume{volume}, nil
+	default: // http2https
+		plugin := &Plugin{
+			Type:              "http2https",
+			LocalAddr:         spec.HTTP2HTTPS.LocalAddr,
+			HostHeaderRewrite: spec.HTTP2HTTPS.HostHeaderRewrite,
+			Headers:           spec.HTTP2HTTPS.Headers,
+		}
+		return plugin, nil, nil
+	}
+}
+
+func pluginVolume(proxy *frpcv1.Proxy, volume frpcv1.PluginVolume) ([]Volume, error) {
+	if n := countSet(volume.PersistentVolumeClaim != nil, volume.ConfigMap != nil); n != 1 {
+		return nil, specError("proxy", proxy.Name, "the plugin volume must set exactly one source, %d are set", n)
+	}
+	return []Volume{{
+		Name:      pluginVolumeName(proxy),
+		MountPath: path.Join(pluginMountRoot, proxy.Name),
+		Source: corev1.VolumeSource{
+			PersistentVolumeClaim: volume.PersistentVolumeClaim,
+			ConfigMap:             volume.ConfigMap,
+		},
+	}}, nil
+}
+
+// pluginVolumeName must be a DNS-1123 label, proxy names may contain dots and be longer.
+func pluginVolumeName(proxy *frpcv1.Proxy) string {
+	hash := sha256.Sum256([]byte(proxy.Name))
+	return "plugin-" + hex.EncodeToString(hash[:])[:16]
+}
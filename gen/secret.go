@@ -0,0 +1,160 @@
+package gen
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Env is a variable of the frpc container holding a key of a Secret. The config only holds
+// its placeholder, frpc fills in the value when it loads the config, so Secret values are
+// never written into the ConfigMap.
+type Env struct {
+	Name   string
+	Source corev1.SecretKeySelector
+	// Revision changes whenever the Secret changes, frpc only reads its environment on start.
+	// Unlike a checksum it does not depend on the value, which could be guessed from it.
+	Revision string
+	// value is kept to check the config frpc ends up with.
+	value string
+}
+
+// Placeholder is written into the config instead of the value.
+func (env Env) Placeholder() string {
+	return envPlaceholder(env.Name)
+}
+
+func envPlaceholder(name string) string {
+	return "{{ .Envs." + name + " }}"
+}
+
+// secretEnvs collects the Secret keys the config refers to.
+type secretEnvs struct {
+	envs []Env
+}
+
+// ref returns the placeholder of a Secret key, keys used more than once share one variable.
+func (s *secretEnvs) ref(ctx context.Context, k8sClient client.Client, namespace string, selector corev1.SecretKeySelector) (string, error) {
+	secret := new(corev1.Secret)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: selector.Name, Namespace: namespace}, secret); err != nil {
+		return "", err
+	}
+	value, ok := secret.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in secret %s/%s", selector.Key, namespace, selector.Name)
+	}
+	hash := sha256.Sum256([]byte(selector.Name + "/" + selector.Key))
+	name := "FRPC_SECRET_" + strings.ToUpper(hex.EncodeToString(hash[:])[:16])
+	for _, env := range s.envs {
+		if env.Name == name {
+			return env.Placeholder(), nil
+		}
+	}
+	env := Env{
+		Name:     name,
+		Source:   selector,
+		Revision: string(secret.UID) + "/" + secret.ResourceVersion,
+		value:    string(value),
+	}
+	s.envs = append(s.envs, env)
+	return env.Placeholder(), nil
+}
+
+// value returns the value behind a placeholder returned by ref.
+func (s *secretEnvs) value(placeholder string) string {
+	for _, env := range s.envs {
+		if env.Placeholder() == placeholder {
+			return env.value
+		}
+	}
+	return ""
+}
+
+// expand fills the Secret values into a rendered config, which is what frpc reads.
+func (config *FrpcConfig) expand(s string) string {
+	if len(config.Envs) == 0 {
+		return s
+	}
+	return config.Render(s, nil)
+}
+
+// Render fills the Secret values and the given variables, e.g. the admin credentials,
+// into a rendered config the way frpc does when it loads the config.
+func (config *FrpcConfig) Render(data string, env map[string]string) string {
+	oldnew := make([]string, 0, 2*(len(config.Envs)+len(env)))
+	for _, e := range config.Envs {
+		oldnew = append(oldnew, e.Placeholder(), e.value)
+	}
+	for _, name := range sortedKeys(env) {
+		oldnew = append(oldnew, envPlaceholder(name), env[name])
+	}
+	return strings.NewReplacer(oldnew...).Replace(data)
+}
+
+func configMapValue(ctx context.Context, k8sClient client.Client, namespace string, selector corev1.ConfigMapKeySelector) (string, error) {
+	configMap := new(corev1.ConfigMap)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: selector.Name, Namespace: namespace}, configMap); err != nil {
+		return "", err
+	}
+	value, ok := configMap.Data[selector.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in configmap %s/%s", selector.Key, namespace, selector.Name)
+	}
+	return value, nil
+}
+
+func tokenValue(ctx context.Context, k8sClient client.Client, clientObj *frpcv1.Client, envs *secretEnvs) (string, error) {
+	token := clientObj.Spec.Common.Token
+	if token.ValueFrom == nil {
+		return token.Value, nil
+	}
+	if token.Value != "" {
+		return "", specError("client", clientObj.Name, "the token sets both value and valueFrom")
+	}
+	if n := countSet(token.ValueFrom.SecretKeyRef != nil, token.ValueFrom.ConfigMapKeyRef != nil); n != 1 {
+		return "", specError("client", clientObj.Name, "the token must set exactly one value source, %d are set", n)
+	}
+	if token.ValueFrom.SecretKeyRef != nil {
+		return envs.ref(ctx, k8sClient, clientObj.Namespace, *token.ValueFrom.SecretKeyRef)
+	}
+	return configMapValue(ctx, k8sClient, clientObj.Namespace, *token.ValueFrom.ConfigMapKeyRef)
+}
+
+// secretVolume mounts the whole Secret, the keys must be present in it. The checksum of the
+// volume changes with the content of the Secret.
+func secretVolume(ctx context.Context, k8sClient client.Client, namespace string, secretName string, volumeName string, mountPath string, keys ...string) (Volume, error) {
+	secret := new(corev1.Secret)
+	if err := k8sClient.Get(ctx, client.ObjectKey{Name: secretName, Namespace: namespace}, secret); err != nil {
+		return Volume{}, err
+	}
+	for _, key := range keys {
+		if _, ok := secret.Data[key]; !ok {
+			return Volume{}, fmt.Errorf("key %s not found in secret %s/%s", key, namespace, secretName)
+		}
+	}
+	dataKeys := make([]string, 0, len(secret.Data))
+	for key := range secret.Data {
+		dataKeys = append(dataKeys, key)
+	}
+	sort.Strings(dataKeys)
+	hash := sha256.New()
+	for _, key := range dataKeys {
+		hash.Write([]byte(key))
+		hash.Write([]byte{0})
+		hash.Write(secret.Data[key])
+		hash.Write([]byte{0})
+	}
+	return Volume{
+		Name:      volumeName,
+		MountPath: mountPath,
+		Source:    corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: secretName}},
+		Checksum:  hex.EncodeToString(hash.Sum(nil)),
+	}, nil
+}
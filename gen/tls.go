@@ -0,0 +1,51 @@
+package gen
+
+import (
+	"context"
+	"path"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	tlsCertMountPath = "/tls/cert"
+	tlsCAMountPath   = "/tls/ca"
+	tlsCAKey         = "ca.crt"
+)
+
+type TLS struct {
+	TLSEnable        bool
+	TLSCertFile      string
+	TLSKeyFile       string
+	TLSTrustedCAFile string
+	TLSServerName    string
+}
+
+func newTLS(ctx context.Context, k8sClient client.Client, clientObj *frpcv1.Client) (TLS, []Volume, error) {
+	spec := clientObj.Spec.Common.TLS
+	if spec == nil {
+		return TLS{}, nil, nil
+	}
+	tls := TLS{TLSEnable: true, TLSServerName: spec.ServerName}
+	var volumes []Volume
+	if spec.CertSecret != "" {
+		volume, err := secretVolume(ctx, k8sClient, clientObj.Namespace, spec.CertSecret, "tls-cert", tlsCertMountPath, corev1.TLSCertKey, corev1.TLSPrivateKeyKey)
+		if err != nil {
+			return TLS{}, nil, err
+		}
+		volumes = append(volumes, volume)
+		tls.TLSCertFile = path.Join(tlsCertMountPath, corev1.TLSCertKey)
+		tls.TLSKeyFile = path.Join(tlsCertMountPath, corev1.TLSPrivateKeyKey)
+	}
+	if spec.TrustedCASecret != "" {
+		volume, err := secretVolume(ctx, k8sClient, clientObj.Namespace, spec.TrustedCASecret, "tls-ca", tlsCAMountPath, tlsCAKey)
+		if err != nil {
+			return TLS{}, nil, err
+		}
+		volumes = append(volumes, volume)
+		tls.TLSTrustedCAFile = path.Join(tlsCAMountPath, tlsCAKey)
+	}
+	return tls, volumes, nil
+}
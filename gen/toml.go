@@ -0,0 +1,188 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// marshalTOML writes the v1 config as toml. It reads the json tags of the v1 types,
+// so all formats share one set of keys, and supports exactly what those types use:
+// strings, ints, bools, string slices, string maps, structs and slices of structs.
+func marshalTOML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeTOMLTable(&buf, nil, reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+type tomlField struct {
+	key   string
+	value reflect.Value
+}
+
+// tomlFields returns the fields of a struct or string map in output order,
+// values which are left out by their omitempty json tag are skipped.
+func tomlFields(v reflect.Value) ([]tomlField, error) {
+	var fields []tomlField
+	switch v.Kind() {
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			name, opts, _ := strings.Cut(t.Field(i).Tag.Get("json"), ",")
+			if name == "" || name == "-" {
+				return nil, fmt.Errorf("toml: field %s.%s has no json name", t.Name(), t.Field(i).Name)
+			}
+			value := v.Field(i)
+			if opts == "omitempty" && value.IsZero() || value.Kind() == reflect.Pointer && value.IsNil() {
+				continue
+			}
+			if (value.Kind() == reflect.Slice || value.Kind() == reflect.Map) && opts == "omitempty" && value.Len() == 0 {
+				continue
+			}
+			fields = append(fields, tomlField{key: name, value: reflect.Indirect(value)})
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return keys[i].String() < keys[j].String() })
+		for _, key := range keys {
+			fields = append(fields, tomlField{key: key.String(), value: v.MapIndex(key)})
+		}
+	default:
+		return nil, fmt.Errorf("toml: cannot write %s as a table", v.Type())
+	}
+	return fields, nil
+}
+
+func isTOMLTable(v reflect.Value) bool {
+	return v.Kind() == reflect.Struct || v.Kind() == reflect.Map
+}
+
+func isTOMLArrayOfTables(v reflect.Value) bool {
+	return v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Struct
+}
+
+// writeTOMLTable writes the key/value pairs of a table first, then its sub tables,
+// as toml requires.
+func writeTOMLTable(buf *bytes.Buffer, path []string, v reflect.Value) error {
+	v = reflect.Indirect(v)
+	fields, err := tomlFields(v)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if isTOMLTable(field.value) || isTOMLArrayOfTables(field.value) {
+			continue
+		}
+		value, err := tomlValue(field.value)
+		if err != nil {
+			return fmt.Errorf("toml: %s: %w", strings.Join(append(path, field.key), "."), err)
+		}
+		fmt.Fprintf(buf, "%s = %s\n", tomlKey(field.key), value)
+	}
+	for _, field := range fields {
+		sub := append(append([]string{}, path...), tomlKey(field.key))
+		switch {
+		case isTOMLTable(field.value):
+			if hasTOMLValues(field.value) {
+				fmt.Fprintf(buf, "\n[%s]\n", strings.Join(sub, "."))
+			}
+			if err := writeTOMLTable(buf, sub, field.value); err != nil {
+				return err
+			}
+		case isTOMLArrayOfTables(field.value):
+			for i := 0; i < field.value.Len(); i++ {
+				fmt.Fprintf(buf, "\n[[%s]]\n", strings.Join(sub, "."))
+				if err := writeTOMLTable(buf, sub, field.value.Index(i)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// hasTOMLValues reports whether a table needs its own header, tables holding only
+// sub tables are defined implicitly by them.
+func hasTOMLValues(v reflect.Value) bool {
+	fields, err := tomlFields(v)
+	if err != nil || len(fields) == 0 {
+		return true
+	}
+	for _, field := range fields {
+		if !isTOMLTable(field.value) && !isTOMLArrayOfTables(field.value) {
+			return true
+		}
+	}
+	return false
+}
+
+func tomlValue(v reflect.Value) (string, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return tomlString(v.String()), nil
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool()), nil
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Slice:
+		values := make([]string, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			value, err := tomlValue(v.Index(i))
+			if err != nil {
+				return "", err
+			}
+			values = append(values, value)
+		}
+		return "[" + strings.Join(values, ", ") + "]", nil
+	default:
+		return "", fmt.Errorf("unsupported type %s", v.Type())
+	}
+}
+
+var bareTOMLKey = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+func tomlKey(key string) string {
+	if bareTOMLKey.MatchString(key) {
+		return key
+	}
+	return tomlString(key)
+}
+
+// tomlString quotes s as a basic string, escaping everything toml does not allow verbatim.
+func tomlString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f || r == utf8.RuneError {
+				fmt.Fprintf(&b, `\u%04X`, r)
+			} else {
+				b.WriteRune(r)
+			}
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
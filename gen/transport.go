@@ -0,0 +1,73 @@
+package gen
+
+import (
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+)
+
+// Transport holds the per proxy options of the traffic between frpc and frps.
+type Transport struct {
+	UseEncryption        bool
+	UseCompression       bool
+	BandwidthLimit       string
+	ProxyProtocolVersion string
+}
+
+func newProxyTransport(proxy *frpcv1.Proxy) Transport {
+	return Transport{
+		UseEncryption:        useEncryption(proxy.Spec.UseEncryption),
+		UseCompression:       proxy.Spec.UseCompression,
+		BandwidthLimit:       proxy.Spec.BandwidthLimit,
+		ProxyProtocolVersion: proxy.Spec.ProxyProtocolVersion,
+	}
+}
+
+func newVisitorTransport(visitor *frpcv1.Visitor) Transport {
+	return Transport{
+		UseEncryption:  useEncryption(visitor.Spec.UseEncryption),
+		UseCompression: visitor.Spec.UseCompression,
+	}
+}
+
+// useEncryption keeps encryption on unless it is turned off explicitly.
+func useEncryption(value *bool) bool {
+	return value == nil || *value
+}
+
+// ServerTransport holds the options of the connection between frpc and frps.
+type ServerTransport struct {
+	Protocol               string
+	TCPMux                 *bool
+	PoolCount              int
+	HeartbeatInterval      int
+	HeartbeatTimeout       int
+	DialServerTimeout      int
+	ConnectServerLocalIP   string
+	QUICKeepalivePeriod    int
+	QUICMaxIdleTimeout     int
+	QUICMaxIncomingStreams int
+}
+
+func newServerTransport(clientObj *frpcv1.Client) (ServerTransport, error) {
+	spec := clientObj.Spec.Common.Transport
+	if spec == nil {
+		return ServerTransport{}, nil
+	}
+	transport := ServerTransport{
+		Protocol:             spec.Protocol,
+		TCPMux:               spec.TCPMux,
+		PoolCount:            spec.PoolCount,
+		HeartbeatInterval:    spec.HeartbeatInterval,
+		HeartbeatTimeout:     spec.HeartbeatTimeout,
+		DialServerTimeout:    spec.DialServerTimeout,
+		ConnectServerLocalIP: spec.ConnectServerLocalIP,
+	}
+	if spec.QUIC != nil {
+		if spec.Protocol != "quic" {
+			return ServerTransport{}, specError("client", clientObj.Name, "quic options require the quic protocol")
+		}
+		transport.QUICKeepalivePeriod = spec.QUIC.KeepalivePeriod
+		transport.QUICMaxIdleTimeout = spec.QUIC.MaxIdleTimeout
+		transport.QUICMaxIncomingStreams = spec.QUIC.MaxIncomingStreams
+	}
+	return transport, nil
+}
@@ -0,0 +1,425 @@
+package gen
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The v1 types mirror the toml, yaml and json config of frpc v0.52+, see
+// https://github.com/fatedier/frp/blob/dev/pkg/config/v1/client.go.
+// Optional blocks are pointers so that they are left out instead of overriding frpc defaults.
+
+type v1ClientConfig struct {
+	ServerAddr        string             `json:"serverAddr"`
+	ServerPort        int                `json:"serverPort"`
+	NatHoleSTUNServer string             `json:"natHoleStunServer,omitempty"`
+	DNSServer         string             `json:"dnsServer,omitempty"`
+	Auth              v1Auth             `json:"auth"`
+	Log               *v1Log             `json:"log,omitempty"`
+	WebServer         v1WebServer        `json:"webServer"`
+	Transport         *v1ClientTransport `json:"transport,omitempty"`
+	Proxies           []v1Proxy          `json:"proxies,omitempty"`
+	Visitors          []v1Visitor        `json:"visitors,omitempty"`
+}
+
+type v1Auth struct {
+	Method           string   `json:"method"`
+	AdditionalScopes []string `json:"additionalScopes,omitempty"`
+	Token            string   `json:"token,omitempty"`
+	OIDC             *v1OIDC  `json:"oidc,omitempty"`
+}
+
+type v1OIDC struct {
+	ClientID                 string            `json:"clientID"`
+	ClientSecret             string            `json:"clientSecret"`
+	Audience                 string            `json:"audience,omitempty"`
+	TokenEndpointURL         string            `json:"tokenEndpointURL"`
+	AdditionalEndpointParams map[string]string `json:"additionalEndpointParams,omitempty"`
+}
+
+type v1Log struct {
+	To                string `json:"to,omitempty"`
+	Level             string `json:"level,omitempty"`
+	MaxDays           int    `json:"maxDays,omitempty"`
+	DisablePrintColor bool   `json:"disablePrintColor,omitempty"`
+}
+
+type v1WebServer struct {
+	Addr     string       `json:"addr"`
+	Port     int          `json:"port"`
+	User     string       `json:"user"`
+	Password string       `json:"password"`
+	TLS      *v1TLSConfig `json:"tls,omitempty"`
+}
+
+type v1TLSConfig struct {
+	CertFile string `json:"certFile"`
+	KeyFile  string `json:"keyFile"`
+}
+
+type v1ClientTransport struct {
+	Protocol             string       `json:"protocol,omitempty"`
+	DialServerTimeout    int          `json:"dialServerTimeout,omitempty"`
+	ConnectServerLocalIP string       `json:"connectServerLocalIP,omitempty"`
+	ProxyURL             string       `json:"proxyURL,omitempty"`
+	PoolCount            int          `json:"poolCount,omitempty"`
+	TCPMux               *bool        `json:"tcpMux,omitempty"`
+	HeartbeatInterval    int          `json:"heartbeatInterval,omitempty"`
+	HeartbeatTimeout     int          `json:"heartbeatTimeout,omitempty"`
+	QUIC                 *v1QUIC      `json:"quic,omitempty"`
+	TLS                  *v1ClientTLS `json:"tls,omitempty"`
+}
+
+type v1QUIC struct {
+	KeepalivePeriod    int `json:"keepalivePeriod,omitempty"`
+	MaxIdleTimeout     int `json:"maxIdleTimeout,omitempty"`
+	MaxIncomingStreams int `json:"maxIncomingStreams,omitempty"`
+}
+
+type v1ClientTLS struct {
+	Enable        bool   `json:"enable"`
+	CertFile      string `json:"certFile,omitempty"`
+	KeyFile       string `json:"keyFile,omitempty"`
+	TrustedCaFile string `json:"trustedCaFile,omitempty"`
+	ServerName    string `json:"serverName,omitempty"`
+}
+
+type v1ProxyTransport struct {
+	UseEncryption        bool   `json:"useEncryption"`
+	UseCompression       bool   `json:"useCompression"`
+	BandwidthLimit       string `json:"bandwidthLimit,omitempty"`
+	ProxyProtocolVersion string `json:"proxyProtocolVersion,omitempty"`
+}
+
+type v1LoadBalancer struct {
+	Group    string `json:"group"`
+	GroupKey string `json:"groupKey,omitempty"`
+}
+
+type v1HealthCheck struct {
+	Type            string `json:"type"`
+	TimeoutSeconds  int    `json:"timeoutSeconds,omitempty"`
+	MaxFailed       int    `json:"maxFailed,omitempty"`
+	IntervalSeconds int    `json:"intervalSeconds,omitempty"`
+	Path            string `json:"path,omitempty"`
+}
+
+type v1HeaderOperations struct {
+	Set map[string]string `json:"set,omitempty"`
+}
+
+type v1Plugin struct {
+	Type              string              `json:"type"`
+	Username          string              `json:"username,omitempty"`
+	Password          string              `json:"password,omitempty"`
+	HTTPUser          string              `json:"httpUser,omitempty"`
+	HTTPPassword      string              `json:"httpPassword,omitempty"`
+	LocalPath         string              `json:"localPath,omitempty"`
+	StripPrefix       string              `json:"stripPrefix,omitempty"`
+	UnixPath          string              `json:"unixPath,omitempty"`
+	LocalAddr         string              `json:"localAddr,omitempty"`
+	CrtPath           string              `json:"crtPath,omitempty"`
+	KeyPath           string              `json:"keyPath,omitempty"`
+	HostHeaderRewrite string              `json:"hostHeaderRewrite,omitempty"`
+	RequestHeaders    *v1HeaderOperations `json:"requestHeaders,omitempty"`
+}
+
+type v1Proxy struct {
+	Name              string              `json:"name"`
+	Type              string              `json:"type"`
+	Transport         v1ProxyTransport    `json:"transport"`
+	LoadBalancer      *v1LoadBalancer     `json:"loadBalancer,omitempty"`
+	HealthCheck       *v1HealthCheck      `json:"healthCheck,omitempty"`
+	LocalIP           string              `json:"localIP,omitempty"`
+	LocalPort         int                 `json:"localPort,omitempty"`
+	Plugin            *v1Plugin           `json:"plugin,omitempty"`
+	RemotePort        int                 `json:"remotePort,omitempty"`
+	SecretKey         string              `json:"secretKey,omitempty"`
+	Multiplexer       string              `json:"multiplexer,omitempty"`
+	CustomDomains     []string            `json:"customDomains,omitempty"`
+	SubDomain         string              `json:"subdomain,omitempty"`
+	Locations         []string            `json:"locations,omitempty"`
+	HTTPUser          string              `json:"httpUser,omitempty"`
+	HTTPPassword      string              `json:"httpPassword,omitempty"`
+	HostHeaderRewrite string              `json:"hostHeaderRewrite,omitempty"`
+	RequestHeaders    *v1HeaderOperations `json:"requestHeaders,omitempty"`
+	RouteByHTTPUser   string              `json:"routeByHTTPUser,omitempty"`
+}
+
+type v1Visitor struct {
+	Name              string           `json:"name"`
+	Type              string           `json:"type"`
+	Transport         v1ProxyTransport `json:"transport"`
+	SecretKey         string           `json:"secretKey"`
+	ServerName        string           `json:"serverName"`
+	BindAddr          string           `json:"bindAddr,omitempty"`
+	BindPort          int              `json:"bindPort"`
+	FallbackTo        string           `json:"fallbackTo,omitempty"`
+	FallbackTimeoutMs int              `json:"fallbackTimeoutMs,omitempty"`
+}
+
+func (config *FrpcConfig) v1() (*v1ClientConfig, error) {
+	// placeholders are written into quoted strings, the values frpc puts in their place
+	// are not escaped.
+	for _, env := range config.Envs {
+		if strings.ContainsAny(env.value, `"'\`) {
+			return nil, &ValidationError{
+				Field:  "secret " + env.Source.Name + "." + env.Source.Key,
+				Value:  env.Placeholder(),
+				Reason: "quotes and backslashes cannot be filled into the " + string(config.Format) + " config",
+			}
+		}
+	}
+	common := config.Common
+	v1 := &v1ClientConfig{
+		ServerAddr:        common.ServerAddress,
+		ServerPort:        common.ServerPort,
+		NatHoleSTUNServer: common.NatHoleSTUNServer,
+		DNSServer:         common.DNSServer,
+		Auth:              common.Auth.v1(),
+		WebServer: v1WebServer{
+			Addr:     common.AdminAddress,
+			Port:     common.AdminPort,
+			User:     common.AdminUsername,
+			Password: common.AdminPassword,
+		},
+	}
+	if common.AdminTLS() {
+		v1.WebServer.TLS = &v1TLSConfig{CertFile: common.AdminTLSCertFile, KeyFile: common.AdminTLSKeyFile}
+	}
+	if common.Log != (Log{}) {
+		v1.Log = &v1Log{
+			To:                common.LogFile,
+			Level:             common.LogLevel,
+			MaxDays:           common.LogMaxDays,
+			DisablePrintColor: common.DisableLogColor,
+		}
+	}
+	v1.Transport = common.v1Transport()
+
+	add := func(name string, proxyType string, local LocalService, transport Transport, lb *LoadBalancer) (*v1Proxy, error) {
+		proxy := v1Proxy{Name: name, Type: proxyType, Transport: transport.v1()}
+		if err := local.v1(name, &proxy); err != nil {
+			return nil, err
+		}
+		if lb != nil && lb.Group != "" {
+			proxy.LoadBalancer = &v1LoadBalancer{Group: lb.Group, GroupKey: lb.GroupKey}
+		}
+		v1.Proxies = append(v1.Proxies, proxy)
+		return &v1.Proxies[len(v1.Proxies)-1], nil
+	}
+	for _, p := range config.TCPProxies {
+		proxy, err := add(p.Name, "tcp", p.LocalService, p.Transport, &p.LoadBalancer)
+		if err != nil {
+			return nil, err
+		}
+		if proxy.RemotePort, err = v1Port(p.Name, "remote_port", p.RemotePort); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range config.UDPProxies {
+		proxy, err := add(p.Name, "udp", p.LocalService, p.Transport, nil)
+		if err != nil {
+			return nil, err
+		}
+		if proxy.RemotePort, err = v1Port(p.Name, "remote_port", p.RemotePort); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range config.HTTPProxies {
+		proxy, err := add(p.Name, "http", p.LocalService, p.Transport, &p.LoadBalancer)
+		if err != nil {
+			return nil, err
+		}
+		proxy.CustomDomains = p.CustomDomains
+		proxy.SubDomain = p.SubDomain
+		proxy.Locations = p.Locations
+		proxy.HostHeaderRewrite = p.HostHeaderRewrite
+		proxy.RequestHeaders = v1Headers(p.Headers)
+		proxy.HTTPUser = p.HTTPUser
+		proxy.HTTPPassword = p.HTTPPwd
+	}
+	for _, p := range config.HTTPSProxies {
+		proxy, err := add(p.Name, "https", p.LocalService, p.Transport, nil)
+		if err != nil {
+			return nil, err
+		}
+		proxy.CustomDomains = p.CustomDomains
+		proxy.SubDomain = p.SubDomain
+	}
+	for _, p := range config.STCPProxies {
+		proxy, err := add(p.Name, "stcp", p.LocalService, p.Transport, nil)
+		if err != nil {
+			return nil, err
+		}
+		proxy.SecretKey = p.SK
+	}
+	for _, p := range config.XTCPProxies {
+		proxy, err := add(p.Name, "xtcp", p.LocalService, p.Transport, nil)
+		if err != nil {
+			return nil, err
+		}
+		proxy.SecretKey = p.SK
+	}
+	for _, p := range config.SUDPProxies {
+		proxy, err := add(p.Name, "sudp", p.LocalService, p.Transport, nil)
+		if err != nil {
+			return nil, err
+		}
+		proxy.SecretKey = p.SK
+	}
+	for _, p := range config.TCPMuxProxies {
+		proxy, err := add(p.Name, "tcpmux", p.LocalService, p.Transport, &p.LoadBalancer)
+		if err != nil {
+			return nil, err
+		}
+		proxy.Multiplexer = p.Multiplexer
+		proxy.CustomDomains = p.CustomDomains
+		proxy.SubDomain = p.SubDomain
+		proxy.RouteByHTTPUser = p.RouteByHTTPUser
+		proxy.HTTPUser = p.HTTPUser
+		proxy.HTTPPassword = p.HTTPPwd
+	}
+
+	for _, v := range config.STCPVisitors {
+		v1.Visitors = append(v1.Visitors, v1Visitor{
+			Name: v.Name, Type: "stcp", Transport: v.Transport.v1(),
+			SecretKey: v.SK, ServerName: v.ServerName, BindAddr: v.BindAddr, BindPort: v.BindPort,
+		})
+	}
+	for _, v := range config.XTCPVisitors {
+		v1.Visitors = append(v1.Visitors, v1Visitor{
+			Name: v.Name, Type: "xtcp", Transport: v.Transport.v1(),
+			SecretKey: v.SK, ServerName: v.ServerName, BindAddr: v.BindAddr, BindPort: v.BindPort,
+			FallbackTo: v.FallbackTo, FallbackTimeoutMs: v.FallbackTimeoutMs,
+		})
+	}
+	for _, v := range config.SUDPVisitors {
+		v1.Visitors = append(v1.Visitors, v1Visitor{
+			Name: v.Name, Type: "sudp", Transport: v.Transport.v1(),
+			SecretKey: v.SK, ServerName: v.ServerName, BindAddr: v.BindAddr, BindPort: v.BindPort,
+		})
+	}
+	return v1, nil
+}
+
+func (auth Auth) v1() v1Auth {
+	v1 := v1Auth{Method: auth.AuthenticationMethod, Token: auth.Token}
+	if auth.AuthenticateHeartbeats {
+		v1.AdditionalScopes = append(v1.AdditionalScopes, "HeartBeats")
+	}
+	if auth.AuthenticateNewWorkConns {
+		v1.AdditionalScopes = append(v1.AdditionalScopes, "NewWorkConns")
+	}
+	if auth.AuthenticationMethod == "oidc" {
+		v1.OIDC = &v1OIDC{
+			ClientID:                 auth.OIDCClientID,
+			ClientSecret:             auth.OIDCClientSecret,
+			Audience:                 auth.OIDCAudience,
+			TokenEndpointURL:         auth.OIDCTokenEndpointURL,
+			AdditionalEndpointParams: auth.OIDCAdditionalEndpointParams,
+		}
+	}
+	return v1
+}
+
+// v1Transport leaves tls out unless it is configured, frpc v0.52+ enables it by default.
+func (common ClientCommon) v1Transport() *v1ClientTransport {
+	st := common.ServerTransport
+	transport := v1ClientTransport{
+		Protocol:             st.Protocol,
+		DialServerTimeout:    st.DialServerTimeout,
+		ConnectServerLocalIP: st.ConnectServerLocalIP,
+		ProxyURL:             common.HTTPProxy,
+		PoolCount:            st.PoolCount,
+		TCPMux:               st.TCPMux,
+		HeartbeatInterval:    st.HeartbeatInterval,
+		HeartbeatTimeout:     st.HeartbeatTimeout,
+	}
+	if st.QUICKeepalivePeriod != 0 || st.QUICMaxIdleTimeout != 0 || st.QUICMaxIncomingStreams != 0 {
+		transport.QUIC = &v1QUIC{
+			KeepalivePeriod:    st.QUICKeepalivePeriod,
+			MaxIdleTimeout:     st.QUICMaxIdleTimeout,
+			MaxIncomingStreams: st.QUICMaxIncomingStreams,
+		}
+	}
+	if common.TLSEnable {
+		transport.TLS = &v1ClientTLS{
+			Enable:        true,
+			CertFile:      common.TLSCertFile,
+			KeyFile:       common.TLSKeyFile,
+			TrustedCaFile: common.TLSTrustedCAFile,
+			ServerName:    common.TLSServerName,
+		}
+	}
+	if transport == (v1ClientTransport{}) {
+		return nil
+	}
+	return &transport
+}
+
+func (transport Transport) v1() v1ProxyTransport {
+	return v1ProxyTransport{
+		UseEncryption:        transport.UseEncryption,
+		UseCompression:       transport.UseCompression,
+		BandwidthLimit:       transport.BandwidthLimit,
+		ProxyProtocolVersion: transport.ProxyProtocolVersion,
+	}
+}
+
+func (local LocalService) v1(name string, proxy *v1Proxy) error {
+	if local.Plugin != nil {
+		plugin := local.Plugin
+		proxy.Plugin = &v1Plugin{
+			Type:              plugin.Type,
+			Username:          plugin.User,
+			Password:          plugin.Passwd,
+			HTTPUser:          plugin.HTTPUser,
+			HTTPPassword:      plugin.HTTPPasswd,
+			LocalPath:         plugin.LocalPath,
+			StripPrefix:       plugin.StripPrefix,
+			UnixPath:          plugin.UnixPath,
+			LocalAddr:         plugin.LocalAddr,
+			CrtPath:           plugin.CrtPath,
+			KeyPath:           plugin.KeyPath,
+			HostHeaderRewrite: plugin.HostHeaderRewrite,
+			RequestHeaders:    v1Headers(plugin.Headers),
+		}
+	} else {
+		port, err := v1Port(name, "local_port", local.LocalPort)
+		if err != nil {
+			return err
+		}
+		proxy.LocalIP = local.LocalAddr
+		proxy.LocalPort = port
+	}
+	if hc := local.HealthCheck; hc != nil {
+		proxy.HealthCheck = &v1HealthCheck{
+			Type:            hc.Type,
+			TimeoutSeconds:  hc.TimeoutS,
+			MaxFailed:       hc.MaxFailed,
+			IntervalSeconds: hc.IntervalS,
+			Path:            hc.URL,
+		}
+	}
+	return nil
+}
+
+// v1Port rejects port ranges, they are only supported by the ini config.
+func v1Port(name string, key string, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	port, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, &ValidationError{Field: "proxy " + name + " " + key, Value: value, Reason: "must be a single port in the toml, yaml and json config"}
+	}
+	return port, nil
+}
+
+func v1Headers(headers map[string]string) *v1HeaderOperations {
+	if len(headers) == 0 {
+		return nil
+	}
+	return &v1HeaderOperations{Set: headers}
+}
@@ -0,0 +1,130 @@
+package gen
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError reports a value which cannot be written into the frpc config safely,
+// or a spec which cannot be turned into a config at all. Retrying does not help until
+// the spec changes.
+type ValidationError struct {
+	// Field is the path of the value in the config, e.g. TCPProxies[web].LocalAddr,
+	// or the object whose spec is invalid, e.g. proxy web.
+	Field string
+	// Value is empty when the spec is invalid as a whole.
+	Value  string
+	Reason string
+}
+
+func (e *ValidationError) Error() string {
+	if e.Value == "" {
+		return fmt.Sprintf("invalid %s: %s", e.Field, e.Reason)
+	}
+	return fmt.Sprintf("invalid value %q for %s: %s", e.Value, e.Field, e.Reason)
+}
+
+// specError returns a ValidationError for the spec of the object kind/name.
+func specError(kind string, name string, format string, args ...interface{}) error {
+	return &ValidationError{Field: kind + " " + name, Reason: fmt.Sprintf(format, args...)}
+}
+
+// validate checks every string of the config. frpc renders the config file as a
+// text/template before parsing it, so besides control characters, which could end a
+// line in any format, template actions are rejected too. Fields tagged gen:"template"
+// are set by gen itself and fields tagged gen:"-" are not written into the config.
+// Fields tagged gen:"secret" may hold placeholders of Secret values, they are checked
+// with the values filled in.
+func (config *FrpcConfig) validate() error {
+	return validator{expand: config.expand}.value("", reflect.ValueOf(config).Elem(), false)
+}
+
+type validator struct {
+	expand func(string) string
+}
+
+func (val validator) value(field string, v reflect.Value, secret bool) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return val.value(field, v.Elem(), secret)
+	case reflect.String:
+		if secret {
+			return validateExpanded(field, v.String(), val.expand(v.String()))
+		}
+		return validateString(field, v.String())
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			tag := t.Field(i).Tag.Get("gen")
+			switch tag {
+			case "-", "template":
+				continue
+			}
+			name := joinField(field, t.Field(i).Name)
+			if t.Field(i).Anonymous {
+				name = field
+			}
+			if err := val.value(name, v.Field(i), tag == "secret"); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			elem := reflect.Indirect(v.Index(i))
+			index := fmt.Sprint(i)
+			if elem.Kind() == reflect.Struct {
+				if name := elem.FieldByName("Name"); name.IsValid() && name.Kind() == reflect.String {
+					index = name.String()
+				}
+			}
+			if err := val.value(fmt.Sprintf("%s[%s]", field, index), v.Index(i), secret); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			key := iter.Key().String()
+			if err := validateString(field, key); err != nil {
+				return err
+			}
+			if err := val.value(fmt.Sprintf("%s[%s]", field, key), iter.Value(), secret); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateString(field string, value string) error {
+	return validateExpanded(field, value, value)
+}
+
+// validateExpanded checks the value frpc ends up with, errors report the value as written
+// into the config so they never contain Secret values.
+func validateExpanded(field string, value string, expanded string) error {
+	if !utf8.ValidString(expanded) {
+		return &ValidationError{Field: field, Value: value, Reason: "not valid utf-8"}
+	}
+	for _, r := range expanded {
+		if r < 0x20 || r == 0x7f {
+			return &ValidationError{Field: field, Value: value, Reason: "control characters are not allowed"}
+		}
+	}
+	if strings.Contains(expanded, "{{") {
+		return &ValidationError{Field: field, Value: value, Reason: "frpc would run {{ as a template action"}
+	}
+	return nil
+}
+
+func joinField(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
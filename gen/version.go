@@ -0,0 +1,129 @@
+package gen
+
+import (
+	"fmt"
+	"strings"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+)
+
+// Version of frpc, e.g. v0.44.0.
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+var (
+	// DefaultVersion is run by Clients without frpVersion.
+	DefaultVersion = Version{Major: 0, Minor: 44}
+	// minVersion is the oldest frpc the generated config is known to work with.
+	minVersion = DefaultVersion
+	// v1Since is the first frpc reading the toml, yaml and json config.
+	v1Since = Version{Major: 0, Minor: 52}
+)
+
+func ParseVersion(value string) (Version, error) {
+	var version Version
+	if _, err := fmt.Sscanf(strings.TrimPrefix(value, "v"), "%d.%d.%d", &version.Major, &version.Minor, &version.Patch); err != nil {
+		return Version{}, &ValidationError{Field: "frpVersion", Value: value, Reason: "not a frpc version like v0.52.0"}
+	}
+	return version, nil
+}
+
+func (v Version) AtLeast(other Version) bool {
+	if v.Major != other.Major {
+		return v.Major > other.Major
+	}
+	if v.Minor != other.Minor {
+		return v.Minor > other.Minor
+	}
+	return v.Patch >= other.Patch
+}
+
+// Supports reports whether frpc of version v has the named feature.
+func (v Version) Supports(feature string) bool {
+	for _, f := range features {
+		if f.name == feature {
+			return v.AtLeast(f.since)
+		}
+	}
+	return false
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("v%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+// ClientVersion returns the frpc version the Client runs.
+func ClientVersion(clientObj *frpcv1.Client) (Version, error) {
+	if clientObj.Spec.FrpVersion == "" {
+		return DefaultVersion, nil
+	}
+	return ParseVersion(clientObj.Spec.FrpVersion)
+}
+
+// UnsupportedError is returned when the config uses a feature the frpc version of the Client does not have.
+type UnsupportedError struct {
+	Feature string
+	Version Version
+	Since   Version
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("%s requires frpc %s or newer, the client runs %s", e.Feature, e.Since, e.Version)
+}
+
+// FeatureAdminStop is the POST /api/stop endpoint of the admin api.
+const FeatureAdminStop = "the stop endpoint of the admin api"
+
+// features lists what the config may use with the first frpc version supporting it.
+// Keys which were renamed are handled by the format, ini or v1, chosen for the version.
+// Features without used are not part of the config, they are looked up by Supports.
+var features = []struct {
+	name  string
+	since Version
+	used  func(config *FrpcConfig) bool
+}{
+	{FeatureAdminStop, Version{Major: 0, Minor: 51}, nil},
+	{"the quic protocol", Version{Major: 0, Minor: 46}, func(config *FrpcConfig) bool {
+		return config.Common.Protocol == "quic"
+	}},
+	{"nat_hole_stun_server", Version{Major: 0, Minor: 51}, func(config *FrpcConfig) bool {
+		return config.Common.NatHoleSTUNServer != ""
+	}},
+	{"http_user of tcpmux proxies", Version{Major: 0, Minor: 48}, func(config *FrpcConfig) bool {
+		for _, proxy := range config.TCPMuxProxies {
+			if proxy.HTTPUser != "" {
+				return true
+			}
+		}
+		return false
+	}},
+	{"fallback_to of xtcp visitors", Version{Major: 0, Minor: 51}, func(config *FrpcConfig) bool {
+		for _, visitor := range config.XTCPVisitors {
+			if visitor.FallbackTo != "" {
+				return true
+			}
+		}
+		return false
+	}},
+	{"the toml, yaml and json config", v1Since, func(config *FrpcConfig) bool {
+		return config.Format != FormatINI
+	}},
+	{"admin_tls", v1Since, func(config *FrpcConfig) bool {
+		return config.Common.AdminTLS()
+	}},
+}
+
+func (config *FrpcConfig) checkVersion() error {
+	if !config.Version.AtLeast(minVersion) {
+		return &UnsupportedError{Feature: "the frpc-operator", Version: config.Version, Since: minVersion}
+	}
+	for _, feature := range features {
+		if feature.used != nil && feature.used(config) && !config.Version.AtLeast(feature.since) {
+			return &UnsupportedError{Feature: feature.name, Version: config.Version, Since: feature.since}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package gen
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	frpcv1 "github.com/YoogoC/frpc-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseVersion(t *testing.T) {
+	for value, want := range map[string]Version{
+		"v0.44.0": {Major: 0, Minor: 44},
+		"0.52.3":  {Major: 0, Minor: 52, Patch: 3},
+		"v1.0.0":  {Major: 1},
+	} {
+		got, err := ParseVersion(value)
+		if err != nil || got != want {
+			t.Errorf("ParseVersion(%q) = %v, %v, want %v", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "latest", "v0.52"} {
+		_, err := ParseVersion(value)
+		var validationErr *ValidationError
+		if !errors.As(err, &validationErr) || validationErr.Field != "frpVersion" {
+			t.Errorf("ParseVersion(%q): got %v, want a ValidationError for frpVersion", value, err)
+		}
+	}
+}
+
+func TestResolveFormat(t *testing.T) {
+	v044, v052 := Version{Major: 0, Minor: 44}, Version{Major: 0, Minor: 52}
+	tests := []struct {
+		format  string
+		version Version
+		want    Format
+	}{
+		{"", v044, FormatINI},
+		{"", v052, FormatTOML},
+		{"ini", v052, FormatINI},
+		{"yaml", v052, FormatYAML},
+		// rejected by checkVersion, not here
+		{"json", v044, FormatJSON},
+	}
+	for _, test := range tests {
+		got, err := ResolveFormat(test.format, test.version)
+		if err != nil || got != test.want {
+			t.Errorf("ResolveFormat(%q, %s) = %s, %v, want %s", test.format, test.version, got, err, test.want)
+		}
+	}
+	var validationErr *ValidationError
+	if _, err := ResolveFormat("xml", v052); !errors.As(err, &validationErr) {
+		t.Errorf("got %v for an unknown format, want a ValidationError", err)
+	}
+}
+
+func TestVersionSupports(t *testing.T) {
+	if (Version{Major: 0, Minor: 48}).Supports(FeatureAdminStop) {
+		t.Error("frpc v0.48.0 has no stop endpoint")
+	}
+	if !(Version{Major: 0, Minor: 51}).Supports(FeatureAdminStop) {
+		t.Error("frpc v0.51.0 has the stop endpoint")
+	}
+	if (Version{Major: 1}).Supports("unknown feature") {
+		t.Error("an unknown feature is supported")
+	}
+}
+
+func TestCheckVersion(t *testing.T) {
+	sk := &corev1.Secret{Data: map[string][]byte{"sk": []byte("sk")}}
+	sk.Name, sk.Namespace = "sk", "default"
+	newVisitor := func(name string) frpcv1.Visitor {
+		visitor := frpcv1.Visitor{}
+		visitor.Name, visitor.Namespace = name, "default"
+		visitor.Spec.Client = "client"
+		return visitor
+	}
+	stcp := newVisitor("db")
+	stcp.Spec.STCPVisitor = &frpcv1.STCPVisitor{ServerName: "db", SK: secretKey("sk", "sk")}
+	xtcp := newVisitor("db-p2p")
+	xtcp.Spec.XTCPVisitor = &frpcv1.XTCPVisitor{ServerName: "db", SK: secretKey("sk", "sk"), FallbackTo: "db"}
+	tcpmux := frpcv1.Proxy{}
+	tcpmux.Name, tcpmux.Namespace = "mux", "default"
+	tcpmux.Spec.Client = "client"
+	tcpmux.Spec.LocalAddr, tcpmux.Spec.LocalPort = "127.0.0.1", "8080"
+	tcpmux.Spec.TCPMuxProxy = &frpcv1.TCPMuxProxy{CustomDomains: []string{"example.com"}, HTTPUser: "user", HTTPPwd: "pwd"}
+
+	tests := []struct {
+		name     string
+		format   string
+		set      func(clientObj *frpcv1.Client)
+		proxies  []frpcv1.Proxy
+		visitors []frpcv1.Visitor
+		feature  string
+		accepted string
+	}{
+		{"toml", "toml", nil, nil, nil, "the toml, yaml and json config", "v0.52.0"},
+		{"yaml", "yaml", nil, nil, nil, "the toml, yaml and json config", "v0.52.0"},
+		{"json", "json", nil, nil, nil, "the toml, yaml and json config", "v0.52.0"},
+		{"quic", "ini", func(clientObj *frpcv1.Client) {
+			clientObj.Spec.Common.Transport = &frpcv1.ClientTransport{Protocol: "quic"}
+		}, nil, nil, "the quic protocol", "v0.46.0"},
+		{"stun server", "ini", func(clientObj *frpcv1.Client) {
+			clientObj.Spec.Common.NatHoleSTUNServer = "stun.example.com:3478"
+		}, nil, nil, "nat_hole_stun_server", "v0.51.0"},
+		{"tcpmux http_user", "ini", nil, []frpcv1.Proxy{tcpmux}, nil, "http_user of tcpmux proxies", "v0.48.0"},
+		{"xtcp fallback", "ini", nil, nil, []frpcv1.Visitor{stcp, xtcp}, "fallback_to of xtcp visitors", "v0.51.0"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			accepted, err := ParseVersion(test.accepted)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, version := range []string{"v0.44.0", "v0.45.0", "v0.47.0", "v0.50.0"} {
+				if v, _ := ParseVersion(version); v.AtLeast(accepted) {
+					continue
+				}
+				clientObj := newClientObj(version, test.format)
+				if test.set != nil {
+					test.set(clientObj)
+				}
+				_, err := Gen(context.Background(), newFakeClient(t, sk), clientObj, test.proxies, test.visitors)
+				var unsupported *UnsupportedError
+				if !errors.As(err, &unsupported) || unsupported.Feature != test.feature || unsupported.Version.String() != version {
+					t.Errorf("%s: got %v, want an UnsupportedError for %s", version, err, test.feature)
+				}
+			}
+			clientObj := newClientObj(test.accepted, test.format)
+			if test.set != nil {
+				test.set(clientObj)
+			}
+			if _, err := Gen(context.Background(), newFakeClient(t, sk), clientObj, test.proxies, test.visitors); err != nil {
+				t.Errorf("%s: %v", test.accepted, err)
+			}
+		})
+	}
+
+	_, err := Gen(context.Background(), newFakeClient(t), newClientObj("v0.43.0", "ini"), nil, nil)
+	var unsupported *UnsupportedError
+	if !errors.As(err, &unsupported) || unsupported.Since != minVersion {
+		t.Errorf("got %v for frpc older than the operator supports, want an UnsupportedError", err)
+	}
+}